@@ -0,0 +1,71 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+	ageArmorFooter = "-----END AGE ENCRYPTED FILE-----"
+)
+
+// WithAgeDecrypt decrypts values which hold age encrypted data in ASCII
+// armor, starting with -----BEGIN AGE ENCRYPTED FILE-----, before they are
+// set. The armor is removed, and the encrypted data passed to decrypt,
+// which is usually a closure around age.Decrypt and the caller's identity:
+//
+//	WithAgeDecrypt(func(src io.Reader) (io.Reader, error) {
+//		return age.Decrypt(src, identity)
+//	})
+//
+// Line breaks in the armor may be real or written as \n. Other values are
+// used as they are. Decrypted values are treated as secrets, and as with
+// WithKMSDecrypt, other options which rewrite values see the plaintext.
+func WithAgeDecrypt(decrypt func(src io.Reader) (io.Reader, error)) Option {
+	return func(o *options) {
+		o.decrypts = append(o.decrypts, func(p *pending) error {
+			if !strings.HasPrefix(strings.TrimSpace(p.value), ageArmorHeader) {
+				return nil
+			}
+			ciphertext, err := dearmorAge(p.value)
+			if err != nil {
+				return err
+			}
+			r, err := decrypt(bytes.NewReader(ciphertext))
+			if err != nil {
+				return fmt.Errorf("unable to decrypt age encrypted value: %w", err)
+			}
+			plaintext, err := io.ReadAll(r)
+			if err != nil {
+				return fmt.Errorf("unable to decrypt age encrypted value: %w", err)
+			}
+			p.value, p.secret = string(plaintext), true
+			return nil
+		})
+	}
+}
+
+// dearmorAge returns the data inside an age ASCII armored value.
+func dearmorAge(value string) ([]byte, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), `\n`, "\n")
+	body := strings.TrimPrefix(value, ageArmorHeader)
+	end := strings.Index(body, ageArmorFooter)
+	if end == -1 {
+		return nil, fmt.Errorf("age armor has no end line")
+	}
+	body = strings.Join(strings.Fields(body[:end]), "")
+	data, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age armor: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeAgeDecrypt "decrypts" data by reversing it.
+func fakeAgeDecrypt(src io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("fail")) {
+		return nil, errors.New("no identity matched")
+	}
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return bytes.NewReader(data), nil
+}
+
+// armor wraps data in age's ASCII armor, using sep between lines.
+func armor(data, sep string) string {
+	return ageArmorHeader + sep + base64.StdEncoding.EncodeToString([]byte(data)) + sep + ageArmorFooter + sep
+}
+
+func TestOverrideWithAgeDecrypt(t *testing.T) {
+
+	source := MapSource{
+		"APP_PASSWORD": armor("2retnuh", "\n"),
+		"APP_TOKEN":    armor("nekot", `\n`),
+		"APP_NAME":     "plain",
+	}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	password := fs.String("password", "", "")
+	token := fs.String("token", "", "")
+	name := fs.String("name", "", "")
+
+	var r Report
+	if err := Override(fs, "APP_", WithSource(source), WithAgeDecrypt(fakeAgeDecrypt), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *password != "hunter2" || *token != "token" || *name != "plain" {
+		t.Errorf("flags were %q, %q, and %q.", *password, *token, *name)
+	}
+	for _, p := range r.Overridden {
+		if p.Flag != "name" && p.Value != redacted {
+			t.Errorf("decrypted value for %v was reported as %q.", p.Flag, p.Value)
+		}
+	}
+}
+
+func TestOverrideWithAgeDecryptErrors(t *testing.T) {
+
+	for _, value := range []string{
+		armor("failure", "\n"),
+		ageArmorHeader + "\nAAAA\n",
+		ageArmorHeader + "\n!!!!\n" + ageArmorFooter,
+	} {
+		fs := flag.NewFlagSet("test", flag.ExitOnError)
+		fs.String("password", "", "")
+		err := Override(fs, "APP_", WithSource(MapSource{"APP_PASSWORD": value}), WithAgeDecrypt(fakeAgeDecrypt))
+		if err == nil || !strings.Contains(err.Error(), "age") {
+			t.Errorf("Override error was %v, want one about age.", err)
+		}
+	}
+}
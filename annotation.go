@@ -0,0 +1,36 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"regexp"
+)
+
+// annotation matches an [env: NAME] annotation in a usage message.
+var annotation = regexp.MustCompile(`\[env:\s*([^\]\s]+)\s*\]`)
+
+// WithUsageAnnotations makes an annotation like [env: LISTEN_PORT] in a
+// flag's usage message give the exact name of the flag's variable, like
+// BindOpts.Env does, so the names can be chosen without changing how the
+// flags are defined:
+//
+//	flag.Int("port", 80, "listen port [env: LISTEN_PORT]")
+//
+// The prefix and the other naming options don't apply to annotated flags.
+// A name given with BindOpts.Env takes precedence over an annotation.
+func WithUsageAnnotations() Option {
+	return func(o *options) { o.annotations = true }
+}
+
+// annotatedName returns the name in f's usage annotation, if it has one.
+func annotatedName(f *flag.Flag) (string, bool) {
+	m := annotation.FindStringSubmatch(f.Usage)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
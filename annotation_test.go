@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOverrideWithUsageAnnotations(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "listen port [env: LISTEN_PORT]")
+	host := fs.String("host", "", "listen host [env:LISTEN_HOST ]")
+	name := fs.String("name", "", "the name")
+	var token string
+	Bind(fs, &token, "token", "", "the token [env: IGNORED]", BindOpts{Env: "TOKEN"})
+
+	source := MapSource{"LISTEN_PORT": "8080", "LISTEN_HOST": "example.com", "APP_NAME": "app",
+		"APP_PORT": "1", "IGNORED": "x", "TOKEN": "y"}
+	if err := Override(fs, "APP_", WithSource(source), WithUsageAnnotations()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || *host != "example.com" || *name != "app" || token != "y" {
+		t.Errorf("flags were port=%v, host=%q, name=%q and token=%q.", *port, *host, *name, token)
+	}
+
+	// Without the option, the annotation is only text.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	port = fs.Int("port", 80, "listen port [env: LISTEN_PORT]")
+	if err := Override(fs, "APP_", WithSource(source)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 1 {
+		t.Errorf("flag port was %v, want 1.", *port)
+	}
+}
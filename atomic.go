@@ -0,0 +1,110 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// An Atomic is a flag.Value holding a T, which can be read with Load while
+// another goroutine is setting it, for example while Override is reloading
+// configuration. The zero Atomic holds the zero value of T.
+type Atomic[T Bindable] struct {
+	v atomic.Pointer[T]
+}
+
+// AtomicFlag defines a flag in fs with the specified name, default value,
+// and usage string, whose value is held in the returned Atomic.
+func AtomicFlag[T Bindable](fs *flag.FlagSet, name string, value T, usage string) *Atomic[T] {
+	a := new(Atomic[T])
+	a.Store(value)
+	fs.Var(a, name, usage)
+	return a
+}
+
+// Load returns the current value.
+func (a *Atomic[T]) Load() T {
+	if p := a.v.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store replaces the current value with v.
+func (a *Atomic[T]) Store(v T) {
+	a.v.Store(&v)
+}
+
+// Set parses s the same way the flag package parses values of type T,
+// then stores the result.
+func (a *Atomic[T]) Set(s string) error {
+	v, err := parseBindable[T](s)
+	if err != nil {
+		return err
+	}
+	a.Store(v)
+	return nil
+}
+
+// String formats the current value.
+func (a *Atomic[T]) String() string {
+	if a == nil {
+		var zero T
+		return fmt.Sprint(zero)
+	}
+	return fmt.Sprint(a.Load())
+}
+
+// Get returns the current value, so an Atomic is a flag.Getter.
+func (a *Atomic[T]) Get() any {
+	return a.Load()
+}
+
+// IsBoolFlag reports whether T is bool, so boolean Atomic flags can be
+// given on the command line without a value.
+func (a *Atomic[T]) IsBoolFlag() bool {
+	var zero T
+	_, ok := any(zero).(bool)
+	return ok
+}
+
+// parseBindable parses s as a T, the way the flag package does.
+func parseBindable[T Bindable](s string) (T, error) {
+	var zero T
+	var v any
+	var err error
+	switch any(zero).(type) {
+	case bool:
+		v, err = strconv.ParseBool(s)
+	case int:
+		var i int64
+		i, err = strconv.ParseInt(s, 0, strconv.IntSize)
+		v = int(i)
+	case int64:
+		v, err = strconv.ParseInt(s, 0, 64)
+	case uint:
+		var u uint64
+		u, err = strconv.ParseUint(s, 0, strconv.IntSize)
+		v = uint(u)
+	case uint64:
+		v, err = strconv.ParseUint(s, 0, 64)
+	case float64:
+		v, err = strconv.ParseFloat(s, 64)
+	case string:
+		v = s
+	case time.Duration:
+		v, err = time.ParseDuration(s)
+	}
+	if err != nil {
+		return zero, err
+	}
+	return v.(T), nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAtomicFlag(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	b := AtomicFlag(fs, "bool", false, "")
+	i := AtomicFlag(fs, "int", 1, "")
+	i64 := AtomicFlag(fs, "int64", int64(1), "")
+	u := AtomicFlag(fs, "uint", uint(1), "")
+	u64 := AtomicFlag(fs, "uint64", uint64(1), "")
+	fl := AtomicFlag(fs, "float", 0.1, "")
+	s := AtomicFlag(fs, "string", "default", "")
+	d := AtomicFlag(fs, "duration", time.Second, "")
+	if fs.Lookup("duration").DefValue != "1s" {
+		t.Errorf("DefValue was %q, want \"1s\".", fs.Lookup("duration").DefValue)
+	}
+
+	fs.Parse([]string{"-bool", "-int", "0x10"})
+	source := MapSource{
+		"APP_INT64":    "2",
+		"APP_UINT":     "2",
+		"APP_UINT64":   "2",
+		"APP_FLOAT":    "0.2",
+		"APP_STRING":   "newvalue",
+		"APP_DURATION": "2s",
+	}
+	if err := Override(fs, "APP_", WithSource(source)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if !b.Load() || i.Load() != 16 || i64.Load() != 2 || u.Load() != 2 || u64.Load() != 2 ||
+		fl.Load() != 0.2 || s.Load() != "newvalue" || d.Load() != 2*time.Second {
+		t.Error("An atomic flag was not set.")
+	}
+	if v, err := Get[time.Duration](fs, "duration"); err != nil || v != 2*time.Second {
+		t.Errorf("Get returned %v, %v.", v, err)
+	}
+	if err := Override(fs, "APP_", WithSource(MapSource{"APP_INT64": "two"})); err == nil {
+		t.Error("Overriding an atomic int64 flag with a string didn't cause an error.")
+	}
+
+	var zero Atomic[int]
+	if zero.Load() != 0 || zero.String() != "0" {
+		t.Error("The zero Atomic didn't hold the zero value.")
+	}
+}
+
+func TestAtomicFlagConcurrentOverride(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := AtomicFlag(fs, "port", 80, "")
+	source := MapSource{"APP_PORT": "8080"}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				Override(fs, "APP_", WithSource(source))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if p := port.Load(); p != 80 && p != 8080 {
+					t.Errorf("read a torn value %v.", p)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
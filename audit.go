@@ -0,0 +1,63 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// An AuditRecord is one line of an audit log, describing a flag which
+// Override set. Secret values are redacted.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Flag     string    `json:"flag"`
+	Var      string    `json:"var"`
+	Source   string    `json:"source"`
+	Old      string    `json:"old"`
+	New      string    `json:"new"`
+	Replaced bool      `json:"replaced,omitempty"` // Whether the flag had already been set.
+}
+
+// WithAuditLog makes Override write an AuditRecord to w, as a line of JSON,
+// for every flag it sets, including those set by later calls which reload
+// the configuration. Override returns an error if a record can't be
+// written, after setting the flag. OpenAuditLog opens a file suitable for w.
+func WithAuditLog(w io.Writer) Option {
+	return func(o *options) { o.audit = w }
+}
+
+// OpenAuditLog opens the file at path for appending audit records, creating
+// it, readable only by its owner, if it doesn't exist.
+func OpenAuditLog(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+}
+
+// writeAudit writes a record of p being set to the audit log, if there is one.
+func (o *options) writeAudit(p pending, record Provenance, previous string) error {
+	if o.audit == nil {
+		return nil
+	}
+	line, err := json.Marshal(AuditRecord{
+		Time:     record.Time,
+		Flag:     record.Flag,
+		Var:      record.Var,
+		Source:   record.Source,
+		Old:      p.redact(previous),
+		New:      record.Value,
+		Replaced: record.Replaced,
+	})
+	if err == nil {
+		_, err = o.audit.Write(append(line, '\n'))
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write audit record for flag %v: %w", p.flag.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOverrideWithAuditLog(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	var token string
+	Bind(fs, &token, "token", "", "", BindOpts{Secret: true})
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for _, port := range []string{"8080", "8081"} {
+		log, err := OpenAuditLog(path)
+		if err != nil {
+			t.Fatalf("OpenAuditLog returned an error: %v", err)
+		}
+		source := MapSource{"APP_PORT": port, "APP_TOKEN": "hunter2"}
+		err = Override(fs, "APP_", WithSource(source), WithAuditLog(log))
+		log.Close()
+		if err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open the audit log: %v", err)
+	}
+	defer f.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("unable to read audit record %q: %v", scanner.Text(), err)
+		}
+		if r.Time.IsZero() {
+			t.Errorf("audit record %q has no time.", scanner.Text())
+		}
+		r.Time = time.Time{}
+		records = append(records, r)
+	}
+
+	want := []AuditRecord{
+		{Flag: "port", Var: "APP_PORT", Source: "overridefromenv.MapSource", Old: "80", New: "8080"},
+		{Flag: "token", Var: "APP_TOKEN", Source: "overridefromenv.MapSource", Old: redacted, New: redacted},
+		{Flag: "port", Var: "APP_PORT", Source: "overridefromenv.MapSource", Old: "8080", New: "8081"},
+		{Flag: "token", Var: "APP_TOKEN", Source: "overridefromenv.MapSource", Old: redacted, New: redacted},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("audit log has %v records, want %v.", len(records), len(want))
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("audit record %v is %+v, want %+v.", i, records[i], want[i])
+		}
+	}
+}
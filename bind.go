@@ -0,0 +1,118 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"runtime"
+	"sync"
+	"time"
+	"weak"
+)
+
+// ErrRequired is wrapped by the error Override returns when a flag bound
+// with Required is set neither on the command line nor from the environment.
+var ErrRequired = errors.New("flag is required")
+
+// redacted replaces the values of secret flags in errors and reports.
+const redacted = "[REDACTED]"
+
+// BindOpts declares how a flag defined with Bind is treated by Override.
+type BindOpts struct {
+	// Env is the exact name of the environment variable for the flag.
+	// The prefix and the other naming options don't apply to it.
+	// If Env is empty, the name is derived as usual.
+	Env string
+
+	// Secret keeps the flag's value out of errors and reports.
+	Secret bool
+
+	// Required makes Override return an error wrapping ErrRequired if the
+	// flag is set neither on the command line nor from the environment.
+	Required bool
+
+	// Path marks the flag's value as a file system path, like WithPaths.
+	Path bool
+}
+
+// Bindable lists the types Bind can define flags for.
+type Bindable interface {
+	bool | int | int64 | uint | uint64 | float64 | string | time.Duration
+}
+
+// bindings holds the BindOpts of the flags defined with Bind. The flags
+// are held weakly, and their entries removed once they are collected, so
+// binding flags doesn't keep them, or the FlagSets holding them, alive.
+var bindings = struct {
+	sync.Mutex
+	m map[weak.Pointer[flag.Flag]]BindOpts
+}{m: make(map[weak.Pointer[flag.Flag]]BindOpts)}
+
+// Bind defines a flag in fs with the specified name, default value, and
+// usage string, like the flag package's Var functions do for each type.
+// The argument p points to a variable in which to store the value of the flag.
+// The options are recorded with the flag and honored by Override.
+func Bind[T Bindable](fs *flag.FlagSet, p *T, name string, value T, usage string, opts BindOpts) {
+	switch p := any(p).(type) {
+	case *bool:
+		fs.BoolVar(p, name, any(value).(bool), usage)
+	case *int:
+		fs.IntVar(p, name, any(value).(int), usage)
+	case *int64:
+		fs.Int64Var(p, name, any(value).(int64), usage)
+	case *uint:
+		fs.UintVar(p, name, any(value).(uint), usage)
+	case *uint64:
+		fs.Uint64Var(p, name, any(value).(uint64), usage)
+	case *float64:
+		fs.Float64Var(p, name, any(value).(float64), usage)
+	case *string:
+		fs.StringVar(p, name, any(value).(string), usage)
+	case *time.Duration:
+		fs.DurationVar(p, name, any(value).(time.Duration), usage)
+	}
+
+	f := fs.Lookup(name)
+	key := weak.Make(f)
+	bindings.Lock()
+	defer bindings.Unlock()
+	bindings.m[key] = opts
+	runtime.AddCleanup(f, unbind, key)
+}
+
+// unbind removes the options of a flag which has been collected.
+func unbind(key weak.Pointer[flag.Flag]) {
+	bindings.Lock()
+	defer bindings.Unlock()
+	delete(bindings.m, key)
+}
+
+// binding returns the options f was bound with, if any.
+func binding(f *flag.Flag) (BindOpts, bool) {
+	bindings.Lock()
+	defer bindings.Unlock()
+	b, ok := bindings.m[weak.Make(f)]
+	return b, ok
+}
+
+// isSecret reports whether f was bound with Secret.
+func isSecret(f *flag.Flag) bool {
+	b, ok := binding(f)
+	return ok && b.Secret
+}
+
+// requiredFlags returns the flags in fs which were bound with Required,
+// sorted by name.
+func requiredFlags(fs *flag.FlagSet) []*flag.Flag {
+	var required []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		if b, ok := binding(f); ok && b.Required {
+			required = append(required, f)
+		}
+	})
+	return required
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBind(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	var (
+		b   bool
+		i   int
+		i64 int64
+		u   uint
+		u64 uint64
+		fl  float64
+		s   string
+		d   time.Duration
+	)
+	Bind(fs, &b, "bool", true, "", BindOpts{})
+	Bind(fs, &i, "int", 1, "", BindOpts{})
+	Bind(fs, &i64, "int64", 1, "", BindOpts{})
+	Bind(fs, &u, "uint", 1, "", BindOpts{})
+	Bind(fs, &u64, "uint64", 1, "", BindOpts{})
+	Bind(fs, &fl, "float", 0.1, "", BindOpts{})
+	Bind(fs, &s, "password", "", "", BindOpts{Env: "DB_PASSWORD", Secret: true})
+	Bind(fs, &d, "timeout", time.Second, "", BindOpts{Required: true})
+
+	source := MapSource{
+		"APP_BOOL":     "false",
+		"APP_INT":      "2",
+		"APP_INT64":    "2",
+		"APP_UINT":     "2",
+		"APP_UINT64":   "2",
+		"APP_FLOAT":    "0.2",
+		"APP_PASSWORD": "ignored",
+		"DB_PASSWORD":  "hunter2",
+		"APP_TIMEOUT":  "1m",
+	}
+	var r Report
+	if err := Override(fs, "APP_", WithSource(source), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if b || i != 2 || i64 != 2 || u != 2 || u64 != 2 || fl != 0.2 || d != time.Minute {
+		t.Error("A bound flag was not overwritten.")
+	}
+	if s != "hunter2" {
+		t.Errorf("flag with an explicit variable was %q, want \"hunter2\".", s)
+	}
+	for _, p := range r.Overridden {
+		if p.Flag == "password" && (p.Var != "DB_PASSWORD" || p.Value != redacted) {
+			t.Errorf("Report for the secret flag was %+v.", p)
+		}
+	}
+}
+
+func TestBindSecretError(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	var i int
+	Bind(fs, &i, "pin", 0, "", BindOpts{Secret: true})
+
+	err := Override(fs, "APP_", WithSource(MapSource{"APP_PIN": "secret1234"}))
+	if err == nil || strings.Contains(err.Error(), "secret1234") {
+		t.Errorf("Override error %v, want one without the secret value.", err)
+	}
+}
+
+func TestBindRequired(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	var s string
+	Bind(fs, &s, "token", "", "", BindOpts{Required: true})
+
+	err := Override(fs, "APP_", WithSource(MapSource{}))
+	if !errors.Is(err, ErrRequired) || !strings.Contains(err.Error(), "APP_TOKEN") {
+		t.Errorf("Override error was %v, want one wrapping ErrRequired naming APP_TOKEN.", err)
+	}
+	if err := Override(fs, "APP_", WithSource(MapSource{"APP_TOKEN": "x"})); err != nil {
+		t.Errorf("Override returned an error: %v", err)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ExitOnError)
+	Bind(fs, &s, "token", "", "", BindOpts{Required: true})
+	if err := Load(fs, Layers{Defaults}); !errors.Is(err, ErrRequired) {
+		t.Errorf("Load error was %v, want one wrapping ErrRequired.", err)
+	}
+	if err := Load(fs, Layers{Args([]string{"-token", "x"})}); err != nil {
+		t.Errorf("Load returned an error: %v", err)
+	}
+}
+
+func TestBindCollected(t *testing.T) {
+
+	bound := func() int {
+		bindings.Lock()
+		defer bindings.Unlock()
+		return len(bindings.m)
+	}
+	before := bound()
+	func() {
+		fs := flag.NewFlagSet("test", flag.ExitOnError)
+		for _, name := range []string{"a", "b", "c"} {
+			var s string
+			Bind(fs, &s, name, "", "", BindOpts{Secret: true})
+		}
+	}()
+	if got := bound(); got != before+3 {
+		t.Fatalf("%d flags were bound, want %d.", got, before+3)
+	}
+
+	// The bindings are dropped once the FlagSet is collected.
+	deadline := time.Now().Add(5 * time.Second)
+	for bound() > before && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := bound(); got > before {
+		t.Errorf("%d bindings were kept after their FlagSet was collected.", got-before)
+	}
+}
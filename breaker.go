@@ -0,0 +1,130 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is wrapped by the error returned by a Remote wrapped with a
+// Breaker when the breaker is open, without trying the remote.
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// now returns the current time. Tests replace it.
+var now = time.Now
+
+// A Breaker stops a program which reloads its configuration from hammering
+// a remote service which is down. Once opening the remote has failed
+// Threshold times in a row, the breaker opens, and further attempts fail
+// straight away with ErrBreakerOpen until Cooldown has passed. Then one
+// attempt is let through, while others still fail: if it succeeds the
+// breaker closes, and if it fails the breaker stays open for another
+// Cooldown. A Breaker is safe for concurrent use, and its zero value opens
+// after five failures and cools down for a minute.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool // Whether the attempt after a cooldown is being made.
+	lastErr  error
+}
+
+// A BreakerState describes a Breaker, so a reloader can report that it is
+// running on degraded configuration instead of logging every failure.
+type BreakerState struct {
+	Open     bool      // Whether attempts are being refused.
+	Failures int       // The number of failures in a row.
+	Since    time.Time // When the breaker opened, if it is open.
+	Err      error     // The last failure, if the last attempt failed.
+}
+
+// Wrap returns r with its Open function guarded by b.
+func (b *Breaker) Wrap(r Remote) Remote {
+	open := r.Open
+	r.Open = func(ctx context.Context) (Source, error) {
+		probe, err := b.allow()
+		if err != nil {
+			return nil, err
+		}
+		s, err := open(ctx)
+		b.record(probe, err)
+		return s, err
+	}
+	return r
+}
+
+// State returns the current state of b.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BreakerState{
+		Open:     !b.openedAt.IsZero(),
+		Failures: b.failures,
+		Since:    b.openedAt,
+		Err:      b.lastErr,
+	}
+}
+
+// allow returns an error if b is open and still cooling down, or if it has
+// cooled down and the attempt it lets through is being made. Otherwise it
+// reports whether the caller's attempt is that one.
+func (b *Breaker) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return false, nil
+	}
+	if wait := b.openedAt.Add(b.cooldown()).Sub(now()); wait > 0 {
+		return false, fmt.Errorf("%w after %v failures, retrying in %v: %w", ErrBreakerOpen, b.failures, wait.Round(time.Second), b.lastErr)
+	}
+	if b.probing {
+		return false, fmt.Errorf("%w after %v failures, retrying now: %w", ErrBreakerOpen, b.failures, b.lastErr)
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record notes the result of an attempt, which is the one let through
+// after a cooldown if probe is true.
+func (b *Breaker) record(probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	b.lastErr = err
+	if err == nil {
+		b.failures, b.openedAt = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold() {
+		b.openedAt = now()
+	}
+}
+
+// threshold returns the number of failures which open b.
+func (b *Breaker) threshold() int {
+	if b.Threshold > 0 {
+		return b.Threshold
+	}
+	return 5
+}
+
+// cooldown returns how long b stays open.
+func (b *Breaker) cooldown() time.Duration {
+	if b.Cooldown > 0 {
+		return b.Cooldown
+	}
+	return time.Minute
+}
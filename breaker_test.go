@@ -0,0 +1,129 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	down := errors.New("connection refused")
+	var fail bool
+	calls := 0
+	b := &Breaker{Threshold: 2, Cooldown: time.Minute}
+	r := b.Wrap(Remote{Name: "vault", Open: func(context.Context) (Source, error) {
+		calls++
+		if fail {
+			return nil, down
+		}
+		return MapSource{}, nil
+	}})
+
+	fail = true
+	for i := 0; i < 2; i++ {
+		if _, err := r.Open(context.Background()); !errors.Is(err, down) {
+			t.Errorf("attempt %v returned %v, want the remote's error.", i, err)
+		}
+	}
+	state := b.State()
+	if !state.Open || state.Failures != 2 || !state.Since.Equal(clock) || !errors.Is(state.Err, down) {
+		t.Errorf("state after two failures was %+v.", state)
+	}
+
+	_, err := r.Open(context.Background())
+	if !errors.Is(err, ErrBreakerOpen) || !errors.Is(err, down) || calls != 2 {
+		t.Errorf("open breaker returned %v after %v calls.", err, calls)
+	}
+
+	// After the cooldown one attempt is let through, and a failure reopens it.
+	clock = clock.Add(time.Minute)
+	if _, err := r.Open(context.Background()); !errors.Is(err, down) || calls != 3 {
+		t.Errorf("attempt after the cooldown returned %v after %v calls.", err, calls)
+	}
+	if _, err := r.Open(context.Background()); !errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("breaker didn't reopen, the attempt returned %v.", err)
+	}
+
+	clock = clock.Add(time.Minute)
+	fail = false
+	if _, err := r.Open(context.Background()); err != nil {
+		t.Errorf("attempt after the remote recovered returned %v.", err)
+	}
+	if state := b.State(); state.Open || state.Failures != 0 || state.Err != nil {
+		t.Errorf("state after recovering was %+v.", state)
+	}
+}
+
+func TestBreakerDefaults(t *testing.T) {
+
+	var b Breaker
+	r := b.Wrap(Remote{Open: func(context.Context) (Source, error) { return nil, errors.New("down") }})
+	for i := 0; i < 4; i++ {
+		r.Open(context.Background())
+	}
+	if b.State().Open {
+		t.Error("breaker opened before five failures.")
+	}
+	r.Open(context.Background())
+	if !b.State().Open {
+		t.Error("breaker didn't open after five failures.")
+	}
+}
+
+func TestBreakerProbe(t *testing.T) {
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	b := &Breaker{Threshold: 1, Cooldown: time.Minute}
+	r := b.Wrap(Remote{Name: "vault", Open: func(context.Context) (Source, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		<-release
+		return MapSource{}, nil
+	}})
+	r.Open(context.Background())
+	clock = clock.Add(time.Minute)
+
+	// While the attempt after the cooldown is being made, every other
+	// attempt is refused.
+	const attempts = 8
+	errs := make(chan error)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := r.Open(context.Background())
+			errs <- err
+		}()
+	}
+	for i := 0; i < attempts-1; i++ {
+		if err := <-errs; !errors.Is(err, ErrBreakerOpen) {
+			t.Errorf("a concurrent attempt returned %v, want ErrBreakerOpen.", err)
+		}
+	}
+	close(release)
+	if err := <-errs; err != nil {
+		t.Errorf("the attempt after the cooldown returned %v.", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("the remote was opened %v times, want 2.", n)
+	}
+	if b.State().Open {
+		t.Error("breaker didn't close after the attempt succeeded.")
+	}
+}
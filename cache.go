@@ -0,0 +1,125 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheFile is the format of the file written by WithOfflineCache.
+type cacheFile struct {
+	Fetched time.Time         `json:"fetched"`
+	Values  map[string]string `json:"values"`
+	Secrets []string          `json:"secrets,omitempty"` // The variables holding secrets.
+}
+
+// cachedSource holds the values in a cache, some of which are secrets.
+type cachedSource struct {
+	namedSource
+	secrets map[string]bool
+}
+
+// SecretKey reports whether the variable called key held a secret when it
+// was cached.
+func (s cachedSource) SecretKey(key string) bool {
+	return s.secrets[key]
+}
+
+// WithOfflineCache returns r with the values it fetches saved to the file at
+// path, so that if r can't be opened later, at startup during an outage of
+// the configuration service for example, the last values fetched are used
+// instead. Values served from the file come from a source called
+// "<name> (stale, fetched <time>)" in the Report, and stale is called, if it
+// isn't nil, with the time they were fetched and the reason r couldn't be
+// opened, so the program can say it is running on stale configuration. If
+// there is no cache to fall back on, the original error is returned.
+//
+// The cache holds every value, secrets included, since a cache with the
+// secrets left out couldn't stand in for the source; there is no option to
+// leave them out. The file is created readable only by its owner and
+// should be kept somewhere private. Which variables the source said held
+// secrets, as a SecretSource, is cached with them, so they are still
+// redacted when they are served from the file. Only
+// sources which can list their variables, like all of the remote sources
+// in this package, can be cached; others are passed through as they are.
+// It is an error for the cache not to be writable.
+func WithOfflineCache(r Remote, path string, stale func(fetched time.Time, err error)) Remote {
+	open := r.Open
+	r.Open = func(ctx context.Context) (Source, error) {
+		s, err := open(ctx)
+		if err != nil {
+			cached, cacheErr := readCache(path)
+			if cacheErr != nil {
+				return nil, err
+			}
+			if stale != nil {
+				stale(cached.Fetched, err)
+			}
+			name := fmt.Sprintf("%v (stale, fetched %v)", r.Name, cached.Fetched.Format(time.RFC3339))
+			secrets := make(map[string]bool, len(cached.Secrets))
+			for _, key := range cached.Secrets {
+				secrets[key] = true
+			}
+			return cachedSource{namedSource{MapSource(cached.Values), name}, secrets}, nil
+		}
+		lister, ok := s.(Lister)
+		if !ok {
+			return s, nil
+		}
+		c := cacheFile{Fetched: now(), Values: make(map[string]string)}
+		for _, key := range lister.Keys() {
+			if v, ok := s.Lookup(key); ok {
+				c.Values[key] = v
+				if keySecret(s, key) {
+					c.Secrets = append(c.Secrets, key)
+				}
+			}
+		}
+		if err := writeCache(path, c); err != nil {
+			return nil, fmt.Errorf("unable to write offline cache: %w", err)
+		}
+		return s, nil
+	}
+	return r
+}
+
+// readCache reads the cache in the file at path.
+func readCache(path string) (cacheFile, error) {
+	var c cacheFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// writeCache replaces the file at path with c, through a temporary file in
+// the same directory, so that a crash never leaves a cache half written.
+func writeCache(path string, c cacheFile) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
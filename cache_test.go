@@ -0,0 +1,87 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithOfflineCache(t *testing.T) {
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	path := filepath.Join(t.TempDir(), "vault.json")
+	down := errors.New("connection refused")
+	var fail bool
+	r := Remote{Name: "vault", Open: func(context.Context) (Source, error) {
+		if fail {
+			return nil, down
+		}
+		return CompositeSource(secretSource{namedSource{MapSource{"APP_TOKEN": "s3cret"}, "vault"}}, MapSource{"APP_PORT": "http"}), nil
+	}}
+	var staleAt time.Time
+	var staleErr error
+	cached := WithOfflineCache(r, path, func(fetched time.Time, err error) { staleAt, staleErr = fetched, err })
+
+	// Without a cache, the error is returned.
+	fail = true
+	if _, err := cached.Open(context.Background()); !errors.Is(err, down) {
+		t.Errorf("Open returned %v without a cache, want the remote's error.", err)
+	}
+
+	fail = false
+	if _, err := cached.Open(context.Background()); err != nil {
+		t.Fatalf("Open returned an error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("cache was %v, %v, want a file only its owner can read.", info, err)
+	}
+
+	fail = true
+	s, err := cached.Open(context.Background())
+	if err != nil {
+		t.Fatalf("Open returned an error with a cache: %v", err)
+	}
+	if v, ok := s.Lookup("APP_TOKEN"); !ok || v != "s3cret" {
+		t.Errorf("APP_TOKEN was %q, %v from the cache.", v, ok)
+	}
+	if got := sourceName(s); got != "vault (stale, fetched 2024-01-01T00:00:00Z)" {
+		t.Errorf("source was called %q.", got)
+	}
+	if !staleAt.Equal(clock) || !errors.Is(staleErr, down) {
+		t.Errorf("stale was called with %v and %v.", staleAt, staleErr)
+	}
+
+	// The cached secret is still a secret.
+	if !keySecret(s, "APP_TOKEN") || keySecret(s, "APP_PORT") {
+		t.Error("the cache didn't keep which variables held secrets.")
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("token", 0, "")
+	err = Override(fs, "APP_", WithSource(s))
+	if err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("Override returned %v for the cached secret.", err)
+	}
+}
+
+func TestWithOfflineCacheUnwritable(t *testing.T) {
+
+	r := Remote{Name: "vault", Open: func(context.Context) (Source, error) { return MapSource{}, nil }}
+	cached := WithOfflineCache(r, filepath.Join(t.TempDir(), "missing", "vault.json"), nil)
+	if _, err := cached.Open(context.Background()); err == nil {
+		t.Error("Open didn't return an error for an unwritable cache.")
+	}
+}
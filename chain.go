@@ -0,0 +1,189 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Remote is a Source which has to be fetched, like those returned by
+// DopplerSource or KubernetesSource, along with the time it's allowed.
+type Remote struct {
+	// Name describes the source in errors.
+	Name string
+
+	// Open creates the source, using ctx to bound the time spent.
+	// It is usually a closure around one of the remote source functions:
+	//
+	//	func(ctx context.Context) (Source, error) { return DopplerSource(ctx, opts) }
+	Open func(ctx context.Context) (Source, error)
+
+	// Timeout bounds the time Open may take. Zero means it's bounded only
+	// by the overall budget given to FetchChain.
+	Timeout time.Duration
+}
+
+// FetchChain opens each of the remotes in turn, each within its own timeout
+// and the time left of the overall budget, so that one slow service can't
+// use up the whole startup deadline and leave no time for the fallbacks
+// after it. A budget of zero means only ctx and the remotes' own timeouts
+// apply.
+//
+// The returned Source is a CompositeSource of the remotes which could be
+// opened, in the order given, so earlier remotes take precedence. If any
+// remote couldn't be opened, the error joins the reasons, and the Source
+// holds the rest, so callers which accept degraded configuration can still
+// use it. The Source is nil only if no remote could be opened.
+func FetchChain(ctx context.Context, budget time.Duration, remotes ...Remote) (Source, error) {
+
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	var chain compositeSource
+	var errs []error
+	for _, r := range remotes {
+		s, err := r.open(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to open %v: %w", r.Name, err))
+			continue
+		}
+		chain = append(chain, s)
+	}
+	if len(chain) == 0 && len(remotes) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return chain, errors.Join(errs...)
+}
+
+// open opens r within its timeout.
+func (r Remote) open(ctx context.Context) (Source, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Open(ctx)
+}
+
+// CompositeSource returns a Source which looks up each variable in sources
+// in turn, so earlier sources take precedence over later ones. The Report
+// records, for each flag, which of the sources its value came from, rather
+// than the composite as a whole. It can list its variables if any of its
+// sources can.
+func CompositeSource(sources ...Source) Source {
+	return compositeSource(sources)
+}
+
+// A SourceNamer is a Source which is made up of other sources, and can say
+// which of them holds a variable. The Report records the name it gives for
+// each variable in place of a single name for the whole Source.
+type SourceNamer interface {
+	Source
+
+	// SourceName describes the source holding the variable called key.
+	SourceName(key string) string
+}
+
+// A SecretSource is a Source which can say that some of its variables hold
+// secrets, like a source of passwords fetched from a secrets manager. Values
+// of those variables are redacted in the Report, the audit log and errors,
+// as if their flags were bound with Secret.
+type SecretSource interface {
+	Source
+
+	// SecretKey reports whether the variable called key holds a secret.
+	SecretKey(key string) bool
+}
+
+// keySecret reports whether s says the variable called key holds a secret.
+func keySecret(s Source, key string) bool {
+	if secrets, ok := s.(SecretSource); ok {
+		return secrets.SecretKey(key)
+	}
+	return false
+}
+
+// keySourceName describes the source in s holding the variable called key.
+func keySourceName(s Source, key string) string {
+	if namer, ok := s.(SourceNamer); ok {
+		return namer.SourceName(key)
+	}
+	return sourceName(s)
+}
+
+// compositeSource looks up variables in each of its sources in turn.
+type compositeSource []Source
+
+// Lookup retrieves the value of the variable called key from the first
+// source which has it.
+func (c compositeSource) Lookup(key string) (string, bool) {
+	for _, s := range c {
+		if v, ok := s.Lookup(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// SourceName describes the first source which has the variable called key.
+func (c compositeSource) SourceName(key string) string {
+	for _, s := range c {
+		if _, ok := s.Lookup(key); ok {
+			return keySourceName(s, key)
+		}
+	}
+	return c.String()
+}
+
+// SecretKey reports whether the first source which has the variable called
+// key says it holds a secret.
+func (c compositeSource) SecretKey(key string) bool {
+	for _, s := range c {
+		if _, ok := s.Lookup(key); ok {
+			return keySecret(s, key)
+		}
+	}
+	return false
+}
+
+// Keys returns the names of the variables in the sources which can list
+// them, sorted and without duplicates.
+func (c compositeSource) Keys() []string {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, s := range c {
+		if lister, ok := s.(Lister); ok {
+			for _, key := range lister.Keys() {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String describes the sources in the composite.
+func (c compositeSource) String() string {
+	names := make([]string, len(c))
+	for i, s := range c {
+		names[i] = sourceName(s)
+	}
+	return "composite(" + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowRemote returns a Remote which takes delay to open, unless its
+// context is done first.
+func slowRemote(name string, delay time.Duration, timeout time.Duration, values map[string]string) Remote {
+	return Remote{
+		Name:    name,
+		Timeout: timeout,
+		Open: func(ctx context.Context) (Source, error) {
+			select {
+			case <-time.After(delay):
+				return namedSource{MapSource(values), name}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+}
+
+func TestFetchChain(t *testing.T) {
+
+	vault := slowRemote("vault", time.Hour, 10*time.Millisecond, map[string]string{"APP_TOKEN": "vault"})
+	doppler := slowRemote("doppler", 0, 0, map[string]string{"APP_TOKEN": "doppler", "APP_PORT": "80"})
+	files := slowRemote("files", 0, 0, map[string]string{"APP_PORT": "8080", "APP_HOST": "example.com"})
+
+	start := time.Now()
+	src, err := FetchChain(context.Background(), time.Second, vault, doppler, files)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("FetchChain took %v, the slow remote wasn't cut off.", time.Since(start))
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "vault") {
+		t.Errorf("FetchChain returned %v, want the timeout of vault.", err)
+	}
+	if src == nil {
+		t.Fatal("FetchChain didn't return the remotes which succeeded.")
+	}
+	for key, want := range map[string]string{"APP_TOKEN": "doppler", "APP_PORT": "80", "APP_HOST": "example.com"} {
+		if got, ok := src.Lookup(key); !ok || got != want {
+			t.Errorf("%v was %q, %v, want %q.", key, got, ok, want)
+		}
+	}
+	if got := src.(Lister).Keys(); !reflect.DeepEqual(got, []string{"APP_HOST", "APP_PORT", "APP_TOKEN"}) {
+		t.Errorf("Keys returned %q.", got)
+	}
+	if got := sourceName(src); got != "composite(doppler, files)" {
+		t.Errorf("source was called %q.", got)
+	}
+}
+
+func TestFetchChainBudget(t *testing.T) {
+
+	first := slowRemote("first", time.Hour, 0, nil)
+	second := slowRemote("second", 0, 0, map[string]string{"APP_PORT": "80"})
+	src, err := FetchChain(context.Background(), 20*time.Millisecond, first, second)
+	if src != nil || err == nil || !strings.Contains(err.Error(), "second") {
+		t.Errorf("FetchChain returned %v and %v once the budget was spent.", src, err)
+	}
+
+	src, err = FetchChain(context.Background(), 0)
+	if err != nil || src == nil {
+		t.Errorf("FetchChain returned %v and %v for no remotes.", src, err)
+	}
+}
+
+func TestCompositeSource(t *testing.T) {
+
+	defaults := namedSource{MapSource{"APP_PORT": "80", "APP_HOST": "localhost"}, "defaults"}
+	vault := secretSource{namedSource{MapSource{"APP_TOKEN": "s3cret"}, "vault"}}
+	overrides := MapSource{"APP_PORT": "7777"}
+	src := CompositeSource(overrides, CompositeSource(vault, defaults))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 8080, "")
+	fs.String("host", "", "")
+	fs.String("token", "", "")
+	var r Report
+	if err := Override(fs, "APP_", WithSource(src), WithReport(&r), WithParallelLookups(2)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	want := map[string]string{"host": "defaults", "port": "overridefromenv.MapSource", "token": "vault"}
+	for _, p := range r.Overridden {
+		if p.Source != want[p.Flag] {
+			t.Errorf("flag %v came from %q, want %q.", p.Flag, p.Source, want[p.Flag])
+		}
+		// Only the vault's values are secrets.
+		if (p.Value == redacted) != (p.Flag == "token") {
+			t.Errorf("flag %v was reported with the value %q.", p.Flag, p.Value)
+		}
+	}
+	if len(r.Overridden) != 3 {
+		t.Errorf("report was %+v.", r.Overridden)
+	}
+	if got := sourceName(src); got != "composite(overridefromenv.MapSource, composite(vault, defaults))" {
+		t.Errorf("source was called %q.", got)
+	}
+}
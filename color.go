@@ -0,0 +1,62 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// WithColorVars sets the flag called name from the conventional variables
+// for turning colour output on and off, which are used as they are, without
+// the prefix. In order of precedence:
+//
+//   - FORCE_COLOR turns colour on, unless it is 0 or false, which turn it off.
+//   - NO_COLOR turns colour off when it is set to anything but the empty string.
+//   - CLICOLOR_FORCE turns colour on when it is set to anything but 0.
+//   - CLICOLOR set to 0 turns colour off.
+//
+// A boolean flag is set to true or false. Any other flag, like a string
+// holding auto, always or never, is set to always or never. The flag's own
+// variable and the command line take precedence over these, and when none
+// of them is set, the flag keeps its default, which is usually to decide
+// based on whether the output is a terminal.
+func WithColorVars(name string) Option {
+	return func(o *options) {
+		for _, c := range []struct {
+			name  string
+			color func(value string) (on, ok bool)
+		}{
+			{"FORCE_COLOR", func(v string) (bool, bool) { return v != "0" && !strings.EqualFold(v, "false"), v != "" }},
+			{"NO_COLOR", func(v string) (bool, bool) { return false, v != "" }},
+			{"CLICOLOR_FORCE", func(v string) (bool, bool) { return true, v != "" && v != "0" }},
+			{"CLICOLOR", func(v string) (bool, bool) { return false, v == "0" }},
+		} {
+			o.composites = append(o.composites, composite{
+				name: func(string) string { return c.name },
+				split: func(fs *flag.FlagSet, value string) ([]part, error) {
+					on, ok := c.color(value)
+					if !ok {
+						return nil, nil
+					}
+					return []part{{flag: name, value: colorValue(fs.Lookup(name), on)}}, nil
+				},
+			})
+		}
+	}
+}
+
+// colorValue returns the value which turns colour on or off for f.
+func colorValue(f *flag.Flag, on bool) string {
+	switch {
+	case f != nil && isBoolFlag(f):
+		return strconv.FormatBool(on)
+	case on:
+		return "always"
+	}
+	return "never"
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestWithColorVars(t *testing.T) {
+
+	for _, test := range []struct {
+		env       MapSource
+		bool, str string
+	}{
+		{MapSource{}, "false", "auto"},
+		{MapSource{"NO_COLOR": "1"}, "false", "never"},
+		{MapSource{"NO_COLOR": ""}, "false", "auto"},
+		{MapSource{"NO_COLOR": "1", "FORCE_COLOR": "1"}, "true", "always"},
+		{MapSource{"FORCE_COLOR": "false"}, "false", "never"},
+		{MapSource{"FORCE_COLOR": "0", "CLICOLOR_FORCE": "1"}, "false", "never"},
+		{MapSource{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, "false", "never"},
+		{MapSource{"CLICOLOR_FORCE": "1", "CLICOLOR": "0"}, "true", "always"},
+		{MapSource{"CLICOLOR_FORCE": "0", "CLICOLOR": "0"}, "false", "never"},
+		{MapSource{"CLICOLOR": "1"}, "false", "auto"},
+		{MapSource{"NO_COLOR": "1", "APP_COLOR": "true", "APP_MODE": "always"}, "true", "always"},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		color := fs.Bool("color", false, "")
+		mode := fs.String("mode", "auto", "")
+		err := Override(fs, "APP_", WithSource(test.env), WithColorVars("color"), WithColorVars("mode"))
+		if err != nil {
+			t.Errorf("Override returned an error for %v: %v", test.env, err)
+			continue
+		}
+		if got := fs.Lookup("color").Value.String(); got != test.bool || *mode != test.str {
+			t.Errorf("with %v, flags were %v and %q, want %v and %q.", test.env, *color, *mode, test.bool, test.str)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"time"
+)
+
+// The functions in this file are drop-in replacements for the flag package's
+// functions of the same names. They define flags on flag.CommandLine, and
+// record them so that OverrideRegistered can set them from the environment.
+
+// OverrideRegistered overrides the flags on flag.CommandLine which were
+// defined by the functions in this package, using Override, after
+// flag.Parse has been called. Other flags on flag.CommandLine are left alone.
+func OverrideRegistered(prefix string, opts ...Option) error {
+	opts = append(opts, withFilter(func(f *flag.Flag) bool {
+		_, ok := binding(f)
+		return ok
+	}))
+	return Override(flag.CommandLine, prefix, opts...)
+}
+
+// Bool defines a bool flag with specified name, default value, and usage string.
+// The return value is the address of a bool variable that stores the value of the flag.
+func Bool(name string, value bool, usage string) *bool {
+	p := new(bool)
+	BoolVar(p, name, value, usage)
+	return p
+}
+
+// BoolVar defines a bool flag with specified name, default value, and usage string.
+// The argument p points to a bool variable in which to store the value of the flag.
+func BoolVar(p *bool, name string, value bool, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// Duration defines a time.Duration flag with specified name, default value, and usage string.
+// The return value is the address of a time.Duration variable that stores the value of the flag.
+func Duration(name string, value time.Duration, usage string) *time.Duration {
+	p := new(time.Duration)
+	DurationVar(p, name, value, usage)
+	return p
+}
+
+// DurationVar defines a time.Duration flag with specified name, default value, and usage string.
+// The argument p points to a time.Duration variable in which to store the value of the flag.
+func DurationVar(p *time.Duration, name string, value time.Duration, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// Float64 defines a float64 flag with specified name, default value, and usage string.
+// The return value is the address of a float64 variable that stores the value of the flag.
+func Float64(name string, value float64, usage string) *float64 {
+	p := new(float64)
+	Float64Var(p, name, value, usage)
+	return p
+}
+
+// Float64Var defines a float64 flag with specified name, default value, and usage string.
+// The argument p points to a float64 variable in which to store the value of the flag.
+func Float64Var(p *float64, name string, value float64, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// Int defines a int flag with specified name, default value, and usage string.
+// The return value is the address of a int variable that stores the value of the flag.
+func Int(name string, value int, usage string) *int {
+	p := new(int)
+	IntVar(p, name, value, usage)
+	return p
+}
+
+// IntVar defines a int flag with specified name, default value, and usage string.
+// The argument p points to a int variable in which to store the value of the flag.
+func IntVar(p *int, name string, value int, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// Int64 defines a int64 flag with specified name, default value, and usage string.
+// The return value is the address of a int64 variable that stores the value of the flag.
+func Int64(name string, value int64, usage string) *int64 {
+	p := new(int64)
+	Int64Var(p, name, value, usage)
+	return p
+}
+
+// Int64Var defines a int64 flag with specified name, default value, and usage string.
+// The argument p points to a int64 variable in which to store the value of the flag.
+func Int64Var(p *int64, name string, value int64, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// String defines a string flag with specified name, default value, and usage string.
+// The return value is the address of a string variable that stores the value of the flag.
+func String(name string, value string, usage string) *string {
+	p := new(string)
+	StringVar(p, name, value, usage)
+	return p
+}
+
+// StringVar defines a string flag with specified name, default value, and usage string.
+// The argument p points to a string variable in which to store the value of the flag.
+func StringVar(p *string, name string, value string, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// Uint defines a uint flag with specified name, default value, and usage string.
+// The return value is the address of a uint variable that stores the value of the flag.
+func Uint(name string, value uint, usage string) *uint {
+	p := new(uint)
+	UintVar(p, name, value, usage)
+	return p
+}
+
+// UintVar defines a uint flag with specified name, default value, and usage string.
+// The argument p points to a uint variable in which to store the value of the flag.
+func UintVar(p *uint, name string, value uint, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
+
+// Uint64 defines a uint64 flag with specified name, default value, and usage string.
+// The return value is the address of a uint64 variable that stores the value of the flag.
+func Uint64(name string, value uint64, usage string) *uint64 {
+	p := new(uint64)
+	Uint64Var(p, name, value, usage)
+	return p
+}
+
+// Uint64Var defines a uint64 flag with specified name, default value, and usage string.
+// The argument p points to a uint64 variable in which to store the value of the flag.
+func Uint64Var(p *uint64, name string, value uint64, usage string) {
+	Bind(flag.CommandLine, p, name, value, usage, BindOpts{})
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestOverrideRegistered(t *testing.T) {
+
+	old := flag.CommandLine
+	defer func() { flag.CommandLine = old }()
+	flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+
+	b := Bool("bool", false, "")
+	d := Duration("duration", time.Second, "")
+	fl := Float64("float", 0.1, "")
+	i := Int("int", 1, "")
+	i64 := Int64("int64", 1, "")
+	s := String("string", "default", "")
+	u := Uint("uint", 1, "")
+	u64 := Uint64("uint64", 1, "")
+	plain := flag.String("plain", "default", "")
+	flag.CommandLine.Parse([]string{"-int", "3"})
+
+	source := MapSource{
+		"APP_BOOL":     "true",
+		"APP_DURATION": "2s",
+		"APP_FLOAT":    "0.2",
+		"APP_INT":      "2",
+		"APP_INT64":    "2",
+		"APP_STRING":   "newvalue",
+		"APP_UINT":     "2",
+		"APP_UINT64":   "2",
+		"APP_PLAIN":    "newvalue",
+	}
+	if err := OverrideRegistered("APP_", WithSource(source)); err != nil {
+		t.Fatalf("OverrideRegistered returned an error: %v", err)
+	}
+	if !*b || *d != 2*time.Second || *fl != 0.2 || *i64 != 2 || *s != "newvalue" || *u != 2 || *u64 != 2 {
+		t.Error("A registered flag was not overwritten.")
+	}
+	if *i != 3 {
+		t.Error("A registered flag set on the command line was overwritten.")
+	}
+	if *plain != "default" {
+		t.Error("A flag defined with the flag package was overwritten.")
+	}
+}
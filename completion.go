@@ -0,0 +1,108 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Shell is a shell which WriteCompletion can write a script for.
+type Shell string
+
+// The shells supported by WriteCompletion.
+const (
+	Bash Shell = "bash"
+	Zsh  Shell = "zsh"
+	Fish Shell = "fish"
+)
+
+// WriteCompletion writes a script to w which completes the flags in fs for
+// the program named after fs, in the given shell. The description of each
+// flag is the first line of its usage message, followed by the environment
+// variable Override looks for, like "[env: APP_PORT]", so users come across
+// the variables while completing flags. Bash can't show descriptions, so
+// its script only completes the flag names. It accepts the same options as
+// Override.
+func WriteCompletion(w io.Writer, shell Shell, fs *flag.FlagSet, prefix string, opts ...Option) error {
+
+	program := fs.Name()
+	if program == "" {
+		return fmt.Errorf("unable to write %v completion: the flag set has no name", shell)
+	}
+	o := newOptions(opts)
+	bw := bufio.NewWriter(w)
+
+	switch shell {
+	case Bash:
+		var names []string
+		fs.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+		function := "_" + strings.Map(shellIdentifier, program) + "_completion"
+		fmt.Fprintf(bw, "%v() {\n", function)
+		fmt.Fprintf(bw, "    COMPREPLY=($(compgen -W '%v' -- \"${COMP_WORDS[COMP_CWORD]}\"))\n", strings.Join(names, " "))
+		fmt.Fprintf(bw, "}\ncomplete -o default -F %v %v\n", function, program)
+	case Zsh:
+		fmt.Fprintf(bw, "#compdef %v\n\n_arguments \\\n", program)
+		fs.VisitAll(func(f *flag.Flag) {
+			spec := fmt.Sprintf("-%v[%v]", f.Name, zshEscape(completionDescription(fs, prefix, o, f)))
+			if !isBoolFlag(f) {
+				spec += ":" + f.Name + ":"
+			}
+			fmt.Fprintf(bw, "  %v \\\n", shellQuote(spec))
+		})
+		fmt.Fprintf(bw, "  '*:file:_files'\n")
+	case Fish:
+		fs.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(bw, "complete -c %v -o %v", program, f.Name)
+			if !isBoolFlag(f) {
+				fmt.Fprintf(bw, " -r")
+			}
+			fmt.Fprintf(bw, " -d %v\n", shellQuote(completionDescription(fs, prefix, o, f)))
+		})
+	default:
+		return fmt.Errorf("unable to write completion for unsupported shell %q", shell)
+	}
+	return bw.Flush()
+}
+
+// completionDescription describes f for completion, noting its variable.
+func completionDescription(fs *flag.FlagSet, prefix string, o *options, f *flag.Flag) string {
+	usage, _, _ := strings.Cut(f.Usage, "\n")
+	if _, ok := annotatedName(f); ok && o.annotations {
+		return usage
+	}
+	note := "[env: " + o.varNames(fs, prefix, f)[0] + "]"
+	return strings.TrimSpace(usage + " " + note)
+}
+
+// isBoolFlag reports whether f can be given without a value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// shellIdentifier replaces the runes which can't be used in a shell
+// function name.
+func shellIdentifier(r rune) rune {
+	if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return r
+	}
+	return '_'
+}
+
+// zshEscape escapes the characters which are special in an _arguments
+// description.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// shellQuote quotes s in single quotes, for both zsh and fish.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+
+	fs := flag.NewFlagSet("my-app", flag.ExitOnError)
+	fs.Int("port", 80, "the port to listen on")
+	fs.Bool("verbose", false, "log what's going on\nin detail")
+
+	tests := []struct {
+		shell Shell
+		want  string
+	}{
+		{Bash, "_my_app_completion() {\n" +
+			"    COMPREPLY=($(compgen -W '-port -verbose' -- \"${COMP_WORDS[COMP_CWORD]}\"))\n" +
+			"}\ncomplete -o default -F _my_app_completion my-app\n"},
+		{Zsh, "#compdef my-app\n\n_arguments \\\n" +
+			"  '-port[the port to listen on \\[env\\: APP_PORT\\]]:port:' \\\n" +
+			"  '-verbose[log what'\\''s going on \\[env\\: APP_VERBOSE\\]]' \\\n" +
+			"  '*:file:_files'\n"},
+		{Fish, "complete -c my-app -o port -r -d 'the port to listen on [env: APP_PORT]'\n" +
+			"complete -c my-app -o verbose -d 'log what'\\''s going on [env: APP_VERBOSE]'\n"},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		if err := WriteCompletion(&b, test.shell, fs, "APP_"); err != nil {
+			t.Fatalf("WriteCompletion for %v returned an error: %v", test.shell, err)
+		}
+		if b.String() != test.want {
+			t.Errorf("WriteCompletion for %v wrote\n%v\nwant\n%v", test.shell, b.String(), test.want)
+		}
+	}
+
+	if err := WriteCompletion(&strings.Builder{}, "csh", fs, "APP_"); err == nil {
+		t.Error("WriteCompletion for an unsupported shell didn't return an error.")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// A composite is a variable holding the values of several flags.
+type composite struct {
+	// name returns the name of the variable, given the prefix.
+	name func(prefix string) string
+	// split returns the values the variable holds for each flag in fs.
+	split func(fs *flag.FlagSet, value string) ([]part, error)
+}
+
+// A part is the value a composite variable holds for one flag.
+type part struct {
+	flag   string
+	value  string
+	secret bool
+}
+
+// addComposites adds the flags set by composite variables to overrides,
+// keeping them sorted by flag name. Flags which have their own variables,
+// and flags Override may not set, are left alone. When several composite
+// variables hold a value for the same flag, the first one given wins.
+func (o *options) addComposites(fs *flag.FlagSet, prefix string, set map[string]bool, overrides []pending) ([]pending, error) {
+	if len(o.composites) == 0 {
+		return overrides, nil
+	}
+	have := make(map[string]bool, len(overrides))
+	for _, p := range overrides {
+		have[p.flag.Name] = true
+	}
+	for _, c := range o.composites {
+		name := c.name(prefix)
+		value, found := o.source.Lookup(name)
+		if !found || value == "" && o.emptyAsUnset {
+			continue
+		}
+		parts, err := c.split(fs, value)
+		if err != nil {
+			// The value may well hold secrets, so it's left out.
+			return nil, fmt.Errorf("unable to read environment variable %v: %w", name, err)
+		}
+		for _, part := range parts {
+			f := fs.Lookup(part.flag)
+			if f == nil {
+				return nil, fmt.Errorf("environment variable %v has a value for undefined flag %v", name, part.flag)
+			}
+			if have[f.Name] || o.skipReason(f, set[f.Name]) != "" {
+				continue
+			}
+			have[f.Name] = true
+			t := o.traceFor(f.Name)
+			t.try(name, true, "")
+			t.finish("found in " + name)
+			overrides = append(overrides, pending{flag: f, name: name, value: part.value, secret: part.secret || keySecret(o.source, name),
+				replace: set[f.Name], format: o.errorFormatter, trace: t})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].flag.Name < overrides[j].flag.Name })
+	return overrides, nil
+}
+
+// WithOptionsVar makes Override read the variable made of the prefix
+// followed by OPTIONS, which holds the values of any number of flags in the
+// form of a URL query string, keyed by flag name:
+//
+//	APP_OPTIONS="port=7777&log-level=debug"
+//
+// This suits platforms which limit how many variables can be configured.
+// Keys and values are unescaped, so a value holding an ampersand is written
+// as %26. The flags' own variables take precedence, and when a flag is given
+// more than once, the first value is used. It is an error for a key not to
+// be the name of a defined flag.
+func WithOptionsVar() Option {
+	return func(o *options) {
+		o.composites = append(o.composites, composite{
+			name:  func(prefix string) string { return strings.ToUpper(prefix) + "OPTIONS" },
+			split: splitQuery,
+		})
+	}
+}
+
+// splitQuery returns the values for the flags in the query string value.
+func splitQuery(_ *flag.FlagSet, value string) ([]part, error) {
+	var parts []part
+	for value != "" {
+		var pair string
+		pair, value, _ = strings.Cut(value, "&")
+		if pair == "" {
+			continue
+		}
+		escaped, v, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(escaped)
+		if err != nil {
+			return nil, fmt.Errorf("invalid escape in key %v", escaped)
+		}
+		if v, err = url.QueryUnescape(v); err != nil {
+			return nil, fmt.Errorf("invalid escape in value for %v", key)
+		}
+		parts = append(parts, part{flag: key, value: v})
+	}
+	return parts, nil
+}
+
+// WithJSONVar makes Override read the variable made of the prefix followed
+// by CONFIG_JSON, which holds the values of any number of flags as a JSON
+// object, keyed by flag name:
+//
+//	APP_CONFIG_JSON='{"port": 7777, "log-level": "debug"}'
+//
+// This suits orchestration systems which template a single document.
+// Strings are used as they are, while numbers and booleans are used as they
+// are written, so 7777 is passed to the flag as 7777 and not 7777.0. Nulls
+// are ignored, and other values are an error, as is a key which isn't the
+// name of a defined flag. The flags' own variables take precedence.
+func WithJSONVar() Option {
+	return func(o *options) {
+		o.composites = append(o.composites, composite{
+			name:  func(prefix string) string { return strings.ToUpper(prefix) + "CONFIG_JSON" },
+			split: splitJSON,
+		})
+	}
+}
+
+// splitJSON returns the values for the flags in the JSON object value.
+func splitJSON(_ *flag.FlagSet, value string) ([]part, error) {
+	values, err := jsonValues([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+	var parts []part
+	for key, v := range values {
+		parts = append(parts, part{flag: key, value: v})
+	}
+	return parts, nil
+}
+
+// jsonValues returns the values in the JSON object data, as WithJSONVar
+// describes: strings as they are, numbers and booleans as they're written,
+// and nulls left out.
+func jsonValues(data []byte) (map[string]string, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var object map[string]json.RawMessage
+	if err := d.Decode(&object); err != nil {
+		// The error from the decoder can quote parts of the value.
+		return nil, fmt.Errorf("not a valid JSON object")
+	}
+	values := make(map[string]string, len(object))
+	for key, raw := range object {
+		raw = bytes.TrimSpace(raw)
+		switch {
+		case bytes.Equal(raw, []byte("null")):
+			continue
+		case raw[0] == '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, fmt.Errorf("invalid string for %v", key)
+			}
+			values[key] = s
+		case raw[0] == '[' || raw[0] == '{':
+			return nil, fmt.Errorf("value for %v is not a string, number or boolean", key)
+		default:
+			values[key] = string(raw)
+		}
+	}
+	return values, nil
+}
+
+// URLFlags names the flags which WithURLVar sets from each part of a URL.
+// Parts without a flag name are ignored.
+type URLFlags struct {
+	Scheme   string // Like postgres.
+	Host     string // The host name, without the port.
+	Port     string
+	User     string
+	Password string // Treated as a secret.
+	Database string // The path, without its leading slash.
+	Options  string // The query string, like sslmode=require.
+}
+
+// WithURLVar sets several flags from the parts of the URL in the variable
+// called name, which is used as it is, without the prefix. Platforms like
+// Heroku only provide DATABASE_URL, while programs often have separate
+// flags for the host, port and so on:
+//
+//	WithURLVar("DATABASE_URL", URLFlags{Host: "db-host", Port: "db-port",
+//		User: "db-user", Password: "db-password", Database: "db-name"})
+//
+// The flags' own variables take precedence, and parts of the URL which are
+// empty are ignored. The password is treated as a secret, and it is an error
+// for the variable not to hold a URL, or for a flag not to be defined.
+func WithURLVar(name string, flags URLFlags) Option {
+	return func(o *options) {
+		o.composites = append(o.composites, composite{
+			name:  func(string) string { return name },
+			split: flags.split,
+		})
+	}
+}
+
+// split returns the values for the flags from the URL in value.
+func (flags URLFlags) split(_ *flag.FlagSet, value string) ([]part, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		// The error from url.Parse quotes the URL, password and all.
+		return nil, fmt.Errorf("not a valid URL")
+	}
+	password, _ := u.User.Password()
+	candidates := []part{
+		{flag: flags.Scheme, value: u.Scheme},
+		{flag: flags.Host, value: u.Hostname()},
+		{flag: flags.Port, value: u.Port()},
+		{flag: flags.User, value: u.User.Username()},
+		{flag: flags.Password, value: password, secret: true},
+		{flag: flags.Database, value: strings.TrimPrefix(u.Path, "/")},
+		{flag: flags.Options, value: u.RawQuery},
+	}
+	var parts []part
+	for _, p := range candidates {
+		if p.flag != "" && p.value != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// CredentialsSource returns a Source holding the credentials systemd passes
+// to a service with LoadCredential= or SetCredential=, read from the
+// directory in $CREDENTIALS_DIRECTORY. Each credential's name is put after
+// the prefix and upper cased, like the flag names in VarName, so with the
+// prefix APP_ the credential db-password sets the flag db-password.
+// Credentials are used exactly as they are, without trimming newlines, and
+// are treated as secrets, redacted like the values of flags bound with
+// Secret. It is an error for $CREDENTIALS_DIRECTORY not to be set.
+func CredentialsSource(prefix string) (Source, error) {
+	dir, ok := os.LookupEnv("CREDENTIALS_DIRECTORY")
+	if !ok || dir == "" {
+		return nil, errors.New("no credentials directory, CREDENTIALS_DIRECTORY isn't set")
+	}
+	return wholeFileSource(dir, prefix, "credentials:"+dir)
+}
+
+// SecretVolumeSource returns a Source holding the files in dir, like a
+// Kubernetes Secret mounted as a volume, with one file for each key. Each
+// file's name is put after the prefix and upper cased, like the flag names
+// in VarName, so with the prefix APP_ the file tls-key sets the flag
+// tls-key. Files are used whole, exactly as they are, so PEM keys and
+// certificates keep every line, and are treated as secrets, redacted like
+// the values of flags bound with Secret.
+func SecretVolumeSource(dir, prefix string) (Source, error) {
+	return wholeFileSource(dir, prefix, "volume:"+dir)
+}
+
+// wholeFileSource returns a Source called name holding the files in dir,
+// as secrets, under variables named after them and the prefix.
+func wholeFileSource(dir, prefix, name string) (Source, error) {
+	files, err := readDirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	values := make(MapSource, len(files))
+	for file, data := range files {
+		values[VarName(prefix, file)] = string(data)
+	}
+	return secretSource{namedSource{values, name}}, nil
+}
+
+// readDirFiles returns the contents of the regular files in dir, keyed by
+// name. Hidden files, whose names start with a dot, are left out.
+func readDirFiles(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if name[0] == '.' {
+			continue
+		}
+		// Kubernetes and systemd both use symbolic links, so follow them.
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		files[name] = data
+	}
+	return files, nil
+}
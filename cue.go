@@ -0,0 +1,103 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WriteCUE writes a CUE definition called #Config to w, describing the
+// variables for the flags in fs, for teams which manage their environment
+// configuration with CUE. Like the schema written by WriteJSONSchema, it
+// constrains the variables Override tries first for each flag to strings
+// matching the values the flag accepts, defaulting to the flag's default.
+// Variables for flags bound with Required are regular fields, the others
+// are optional, and other variables are allowed. It accepts the same
+// options as Override.
+func WriteCUE(w io.Writer, fs *flag.FlagSet, prefix string, opts ...Option) error {
+
+	o := newOptions(opts)
+	required := make(map[*flag.Flag]bool)
+	for _, f := range requiredFlags(fs) {
+		required[f] = true
+	}
+
+	bw := bufio.NewWriter(w)
+	if fs.Name() != "" {
+		fmt.Fprintf(bw, "// #Config is the environment of %v.\n", fs.Name())
+	}
+	bw.WriteString("#Config: {\n")
+	fs.VisitAll(func(f *flag.Flag) {
+		for _, line := range strings.Split(f.Usage, "\n") {
+			if line != "" {
+				fmt.Fprintf(bw, "\t// %v\n", line)
+			}
+		}
+		name := cueString(o.varNames(fs, prefix, f)[0])
+		constraint := "string"
+		if _, pattern := flagPattern(f); pattern != "" {
+			constraint = fmt.Sprintf("string & =~#\"%v\"#", pattern)
+		}
+		if required[f] {
+			fmt.Fprintf(bw, "\t%v: %v\n", name, constraint)
+		} else {
+			fmt.Fprintf(bw, "\t%v?: *%v | (%v)\n", name, cueString(f.DefValue), constraint)
+		}
+	})
+	bw.WriteString("\t...\n}\n")
+	return bw.Flush()
+}
+
+// cueString quotes s as a CUE string. CUE's escapes are a superset of JSON's.
+func cueString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// Validate checks the values in the source against the contract described
+// by WriteJSONSchema and WriteCUE, without setting any flags: each variable
+// Override would read for a flag must match the values the flag accepts,
+// and flags bound with Required must have one. It returns an error
+// describing every problem, or nil. It accepts the same options as
+// Override, which decide the variables checked and how their values are
+// transformed before they are matched, so a bare number is a valid duration
+// when WithBareDurations is given, for example. Secret values are redacted
+// in the errors.
+func Validate(fs *flag.FlagSet, prefix string, src Source, opts ...Option) error {
+
+	o := newOptions(append(opts, WithSource(src)))
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		name, value, found, err := o.lookup(fs, prefix, f, nil)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case !found:
+			if b, ok := binding(f); ok && b.Required {
+				errs = append(errs, fmt.Errorf("flag %v must be set with environment variable %v: %w",
+					f.Name, o.varNames(fs, prefix, f)[0], ErrRequired))
+			}
+		default:
+			p := pending{flag: f, name: name, value: value, secret: keySecret(o.source, name), format: o.errorFormatter}
+			if err := o.transform(&p); err != nil {
+				errs = append(errs, err)
+				return
+			}
+			flagType, pattern := flagPattern(f)
+			if pattern != "" && !regexp.MustCompile(pattern).MatchString(p.value) {
+				errs = append(errs, p.error(fmt.Errorf("not a valid %v", flagType)))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
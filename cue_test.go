@@ -0,0 +1,83 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCUE(t *testing.T) {
+
+	fs := flag.NewFlagSet("scanner", flag.ContinueOnError)
+	fs.Int("port", 80, "the port\nto listen on")
+	fs.String("name", "a \"name\"", "")
+	var token string
+	Bind(fs, &token, "token", "", "the token", BindOpts{Required: true})
+
+	var b strings.Builder
+	if err := WriteCUE(&b, fs, "APP_"); err != nil {
+		t.Fatalf("WriteCUE returned an error: %v", err)
+	}
+	want := "// #Config is the environment of scanner.\n" +
+		"#Config: {\n" +
+		"\t\"APP_NAME\"?: *\"a \\\"name\\\"\" | (string)\n" +
+		"\t// the port\n" +
+		"\t// to listen on\n" +
+		"\t\"APP_PORT\"?: *\"80\" | (string & =~#\"" + intPattern + "\"#)\n" +
+		"\t// the token\n" +
+		"\t\"APP_TOKEN\": string\n" +
+		"\t...\n}\n"
+	if b.String() != want {
+		t.Errorf("WriteCUE wrote\n%v\nwant\n%v", b.String(), want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	fs.Bool("verbose", false, "")
+	fs.String("name", "", "")
+	var token string
+	Bind(fs, &token, "token", "", "", BindOpts{Required: true, Secret: true})
+	var key string
+	Bind(fs, &key, "key", "", "", BindOpts{Secret: true})
+
+	if err := Validate(fs, "APP_", MapSource{"APP_PORT": "8080", "APP_TOKEN": "x", "APP_NAME": "anything"}); err != nil {
+		t.Errorf("Validate returned an error for valid values: %v", err)
+	}
+
+	err := Validate(fs, "APP_", MapSource{"APP_PORT": "http", "APP_VERBOSE": "yes", "APP_KEY": "hunter2"})
+	if !errors.Is(err, ErrRequired) {
+		t.Errorf("Validate returned %v, which doesn't wrap ErrRequired.", err)
+	}
+	for _, s := range []string{"APP_PORT", "not a valid int", "APP_VERBOSE", "not a valid bool", "APP_TOKEN"} {
+		if err == nil || !strings.Contains(err.Error(), s) {
+			t.Errorf("Validate returned %v, which doesn't mention %q.", err, s)
+		}
+	}
+	if *port != 80 {
+		t.Errorf("Validate set flag port to %v.", *port)
+	}
+}
+
+func TestValidateTransformed(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Duration("timeout", time.Second, "")
+
+	src := MapSource{"APP_TIMEOUT": "30"}
+	if err := Validate(fs, "APP_", src, WithBareDurations(time.Second)); err != nil {
+		t.Errorf("Validate returned an error for a bare duration: %v", err)
+	}
+	if err := Validate(fs, "APP_", src); err == nil {
+		t.Error("Validate didn't return an error for a bare duration without WithBareDurations.")
+	}
+}
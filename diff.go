@@ -0,0 +1,113 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A ChangeKind describes how a setting differs from its baseline.
+type ChangeKind int
+
+// The kinds of change reported by Diff.
+const (
+	Added   ChangeKind = iota // The setting isn't in the baseline.
+	Removed                   // The setting is only in the baseline.
+	Changed                   // The setting has a different value.
+)
+
+// String returns a short description of k.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// A Change is a setting which differs from its baseline.
+type Change struct {
+	Kind     ChangeKind
+	Var      string // The name of the variable for the setting.
+	Flag     string // The name of the flag, empty for removed settings.
+	Baseline string // The value in the baseline, empty for added settings.
+	Current  string // The current value, empty for removed settings.
+}
+
+// String describes c on a single line.
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %v=%v", c.Var, c.Current)
+	case Removed:
+		return fmt.Sprintf("- %v=%v", c.Var, c.Baseline)
+	}
+	return fmt.Sprintf("~ %v=%v (was %v)", c.Var, c.Current, c.Baseline)
+}
+
+// Diff compares the current value of every flag in fs with the baseline,
+// which is either a Snapshot written by its Write method or a dotenv file
+// like the ones written by WriteDotenv. Settings are matched by variable
+// name, and the changes are returned sorted by it. It accepts the same
+// options as WriteDotenv; as there, secret values are redacted unless
+// WithSecretsIncluded is given, so a redacted value only differs from a
+// baseline which isn't redacted, and isn't reported as added when the
+// baseline left it out.
+func Diff(fs *flag.FlagSet, prefix string, baseline io.Reader, opts ...Option) ([]Change, error) {
+
+	previous, err := readBaseline(baseline)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, v := range effectiveVars(fs, prefix, newOptions(opts)) {
+		value, ok := previous[v.name]
+		delete(previous, v.name)
+		switch {
+		case !ok && v.redacted:
+			// WriteDotenv leaves secrets out.
+		case !ok:
+			changes = append(changes, Change{Kind: Added, Var: v.name, Flag: v.flag.Name, Current: v.value})
+		case value != v.value:
+			changes = append(changes, Change{Kind: Changed, Var: v.name, Flag: v.flag.Name, Baseline: value, Current: v.value})
+		}
+	}
+	// Whatever is left in the baseline no longer has a flag.
+	for name, value := range previous {
+		changes = append(changes, Change{Kind: Removed, Var: name, Baseline: value})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Var < changes[j].Var })
+	return changes, nil
+}
+
+// readBaseline reads the variables in a snapshot or dotenv file.
+func readBaseline(r io.Reader) (MapSource, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read baseline: %w", err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseDotenv(bytes.NewReader(data))
+	}
+	s, err := ReadSnapshot(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	values := make(MapSource)
+	for _, f := range s.Flags {
+		values[f.Var] = f.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Int("port", 8080, "")
+	fs.String("host", "localhost", "")
+	fs.String("name", "app", "")
+
+	want := []Change{
+		{Kind: Added, Var: "APP_HOST", Flag: "host", Current: "localhost"},
+		{Kind: Removed, Var: "APP_LEVEL", Baseline: "debug"},
+		{Kind: Changed, Var: "APP_PORT", Flag: "port", Baseline: "80", Current: "8080"},
+	}
+
+	dotenv := "APP_LEVEL=debug\nAPP_NAME=app\nAPP_PORT=80\n"
+	changes, err := Diff(fs, "APP_", strings.NewReader(dotenv))
+	if err != nil {
+		t.Fatalf("Diff returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff against a dotenv file returned %v, want %v.", changes, want)
+	}
+
+	s := &Snapshot{Flags: []FlagSnapshot{
+		{Name: "level", Var: "APP_LEVEL", Value: "debug"},
+		{Name: "name", Var: "APP_NAME", Value: "app"},
+		{Name: "port", Var: "APP_PORT", Value: "80"},
+	}}
+	var buf bytes.Buffer
+	if err := s.Write(&buf); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+	changes, err = Diff(fs, "APP_", &buf)
+	if err != nil {
+		t.Fatalf("Diff returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff against a snapshot returned %v, want %v.", changes, want)
+	}
+
+	if got := want[2].String(); got != "~ APP_PORT=8080 (was 80)" {
+		t.Errorf("Change.String returned %q.", got)
+	}
+}
+
+func TestDiffSecret(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	var token string
+	Bind(fs, &token, "token", "hunter2", "", BindOpts{Secret: true})
+
+	// WriteDotenv leaves the secret out, which isn't a change.
+	var b strings.Builder
+	if err := WriteDotenv(&b, fs, "APP_"); err != nil {
+		t.Fatalf("WriteDotenv returned an error: %v", err)
+	}
+	changes, err := Diff(fs, "APP_", strings.NewReader(b.String()))
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Diff against the written file returned %v and %v.", changes, err)
+	}
+	s, err := DotenvSource(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("DotenvSource returned an error: %v", err)
+	}
+	if v, ok := s.Lookup("APP_TOKEN"); ok {
+		t.Errorf("the written file held %q for the secret.", v)
+	}
+}
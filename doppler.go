@@ -0,0 +1,62 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// dopplerURL is the Doppler API.
+const dopplerURL = "https://api.doppler.com"
+
+// DopplerOptions says which secrets DopplerSource downloads.
+type DopplerOptions struct {
+	// Token is a service token, which is scoped to a project and config,
+	// or a personal or CLI token, which needs Project and Config.
+	Token           string
+	Project, Config string
+
+	URL    string       // The Doppler API, https://api.doppler.com by default.
+	Client *http.Client // http.DefaultClient if nil.
+}
+
+// DopplerSource returns a Source holding the secrets of a Doppler config,
+// keyed by secret name, so that programs can read them without being run
+// under doppler run. Secret names are used as variable names, so the flag
+// port is set from the secret APP_PORT with the prefix APP_. The secrets
+// are redacted like the values of flags bound with Secret.
+func DopplerSource(ctx context.Context, opts DopplerOptions) (Source, error) {
+
+	base := opts.URL
+	if base == "" {
+		base = dopplerURL
+	}
+	query := url.Values{"format": {"json"}}
+	if opts.Project != "" {
+		query.Set("project", opts.Project)
+	}
+	if opts.Config != "" {
+		query.Set("config", opts.Config)
+	}
+	u := strings.TrimSuffix(base, "/") + "/v3/configs/config/secrets/download?" + query.Encode()
+
+	return fetchSecretSource(ctx, "doppler", func(ctx context.Context) (map[string]string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+		var secrets map[string]string
+		if err := getJSON(opts.Client, req, &secrets); err != nil {
+			return nil, err
+		}
+		return secrets, nil
+	})
+}
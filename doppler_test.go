@@ -0,0 +1,58 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDopplerSource(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.Header.Get("Authorization") != "Bearer dp.pt.token" {
+			http.Error(w, `{"messages": ["Invalid auth token"], "success": false}`, http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v3/configs/config/secrets/download" || q.Get("format") != "json" ||
+			q.Get("project") != "scanner" || q.Get("config") != "prd" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"APP_PORT": "8080", "APP_TOKEN": "hunter2", "DOPPLER_CONFIG": "prd"}`))
+	}))
+	defer srv.Close()
+
+	opts := DopplerOptions{Token: "dp.pt.token", Project: "scanner", Config: "prd", URL: srv.URL}
+	src, err := DopplerSource(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("DopplerSource returned an error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	token := fs.String("token", "", "")
+	var r Report
+	if err := Override(fs, "APP_", WithSource(src), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || *token != "hunter2" {
+		t.Errorf("flags were port=%v and token=%q.", *port, *token)
+	}
+	for _, p := range r.Overridden {
+		if p.Value != redacted {
+			t.Errorf("the secret for flag %v was reported as %q.", p.Flag, p.Value)
+		}
+	}
+
+	opts.Token = "wrong"
+	if _, err := DopplerSource(context.Background(), opts); err == nil {
+		t.Error("DopplerSource didn't return an error when it wasn't authorized.")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DotenvSource returns a Source holding the variables in the dotenv file
+// read from r. Each line holds a KEY=VALUE pair, optionally preceded by
+// export. Blank lines and lines starting with # are ignored. Values may be
+// wrapped in single quotes, which are removed, or double quotes, which are
+// removed after Go escape sequences like \n and \" are interpreted.
+func DotenvSource(r io.Reader) (Source, error) {
+	values, err := parseDotenv(r)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// DotenvFile returns a Source holding the variables in the dotenv file at path.
+// If the file was encrypted by SOPS, it is decrypted as it would be by SopsFile,
+// and its values are treated as secrets.
+func DotenvFile(path string) (Source, error) {
+	values, decrypted, err := readDotenvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return dotenvFileSource(values, "dotenv:"+path, decrypted), nil
+}
+
+// readDotenvFile reads the dotenv file at path, decrypting it if needed,
+// and reports whether it was decrypted.
+func readDotenvFile(path string) (MapSource, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	values, err := parseDotenv(f)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read %v: %w", path, err)
+	}
+	if isSopsDotenv(values) {
+		values, err := decryptSops(path)
+		return values, err == nil, err
+	}
+	return values, false, nil
+}
+
+// dotenvFileSource returns the values read from a dotenv file as a Source
+// called name, whose values are secrets if the file was decrypted.
+func dotenvFileSource(values MapSource, name string, decrypted bool) Source {
+	s := namedSource{values, name}
+	if decrypted {
+		return secretSource{s}
+	}
+	return s
+}
+
+// parseDotenv reads KEY=VALUE pairs from r.
+func parseDotenv(r io.Reader) (MapSource, error) {
+	values := make(MapSource)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+		i := strings.Index(text, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", line)
+		}
+		key, value := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+		value, err := unquoteDotenv(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// unquoteDotenv removes the quotes, if any, around a dotenv value.
+func unquoteDotenv(value string) (string, error) {
+	if len(value) < 2 {
+		return value, nil
+	}
+	switch {
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	case value[0] == '"' && value[len(value)-1] == '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %v", value)
+		}
+		return unquoted, nil
+	}
+	return value, nil
+}
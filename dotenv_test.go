@@ -0,0 +1,50 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDotenvSource(t *testing.T) {
+
+	s, err := DotenvSource(strings.NewReader(`
+# A comment.
+APP_NAME=plain
+export APP_HOST = example.com
+APP_SINGLE='single $quoted'
+APP_DOUBLE="line one\nline two"
+APP_EMPTY=
+APP_EQUALS=a=b
+`))
+	if err != nil {
+		t.Fatalf("DotenvSource returned an error: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"APP_NAME":   "plain",
+		"APP_HOST":   "example.com",
+		"APP_SINGLE": "single $quoted",
+		"APP_DOUBLE": "line one\nline two",
+		"APP_EMPTY":  "",
+		"APP_EQUALS": "a=b",
+	} {
+		got, ok := s.Lookup(key)
+		if !ok || got != want {
+			t.Errorf("Lookup(%q) returned %q, %v, want %q.", key, got, ok, want)
+		}
+	}
+}
+
+func TestDotenvSourceErrors(t *testing.T) {
+
+	for _, input := range []string{"NOEQUALS", "=novalue", `APP_BAD="\q"`} {
+		if _, err := DotenvSource(strings.NewReader(input)); err == nil {
+			t.Errorf("DotenvSource(%q) didn't return an error.", input)
+		}
+	}
+}
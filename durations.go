@@ -0,0 +1,59 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// WithBareDurations makes values for duration flags which are only digits,
+// like 30, mean that many units, instead of being an error. Orchestration
+// systems often template plain numbers into timeout variables. Durations
+// with units, like 30s, are parsed as usual. Duration flags are those whose
+// Value has a Get method, like the flag package's, returning a
+// time.Duration. It is an error for a bare number to be given when unit
+// isn't positive, as it can't be converted.
+func WithBareDurations(unit time.Duration) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, func(p *pending) error {
+			if !isDurationFlag(p.flag) || !allDigits(p.value) {
+				return nil
+			}
+			if unit <= 0 {
+				return fmt.Errorf("bare durations need a positive unit, not %v", unit)
+			}
+			n, err := strconv.ParseInt(p.value, 10, 64)
+			if err != nil || n > int64(1<<63-1)/int64(unit) {
+				return fmt.Errorf("duration of %v units of %v is out of range", p.value, unit)
+			}
+			p.value = (time.Duration(n) * unit).String()
+			return nil
+		})
+	}
+}
+
+// isDurationFlag reports whether f holds a time.Duration.
+func isDurationFlag(f *flag.Flag) bool {
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	_, ok = g.Get().(time.Duration)
+	return ok
+}
+
+// allDigits reports whether s is made up of one or more ASCII digits.
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/base64"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestOverrideWithBareDurations(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 0, "")
+	interval := fs.Duration("interval", 0, "")
+	retries := fs.Int("retries", 0, "")
+	var grace time.Duration
+	Bind(fs, &grace, "grace", 0, "", BindOpts{})
+
+	source := MapSource{"APP_TIMEOUT": "30", "APP_INTERVAL": "1m", "APP_RETRIES": "3", "APP_GRACE": "5"}
+	if err := Override(fs, "APP_", WithSource(source), WithBareDurations(time.Second)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *timeout != 30*time.Second || *interval != time.Minute || *retries != 3 || grace != 5*time.Second {
+		t.Errorf("flags were timeout=%v, interval=%v, retries=%v and grace=%v.", *timeout, *interval, *retries, grace)
+	}
+
+	source = MapSource{"APP_TIMEOUT": "99999999999999999999"}
+	if err := Override(fs, "APP_", WithSource(source), WithBareDurations(time.Hour)); err == nil {
+		t.Error("Override didn't return an error for a duration which is out of range.")
+	}
+}
+
+func TestWithBareDurationsInvalidUnit(t *testing.T) {
+
+	for _, unit := range []time.Duration{0, -time.Second} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		timeout := fs.Duration("timeout", time.Minute, "")
+		err := Override(fs, "APP_", WithSource(MapSource{"APP_TIMEOUT": "30"}), WithBareDurations(unit))
+		if err == nil || *timeout != time.Minute {
+			t.Errorf("with a unit of %v, Override returned %v and timeout was %v.", unit, err, *timeout)
+		}
+	}
+}
+
+func TestWithBareDurationsDecrypted(t *testing.T) {
+
+	// The value is decrypted first, although WithBareDurations comes first.
+	decrypt := func(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+	env := MapSource{"APP_TIMEOUT": kmsPrefix + base64.StdEncoding.EncodeToString([]byte("30"))}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", time.Minute, "")
+	if err := Override(fs, "APP_", WithSource(env), WithBareDurations(time.Second), WithKMSDecrypt(decrypt)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *timeout != 30*time.Second {
+		t.Errorf("timeout was %v, want 30s.", *timeout)
+	}
+}
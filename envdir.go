@@ -0,0 +1,36 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"strings"
+)
+
+// EnvdirSource returns a Source holding the variables in dir, following the
+// envdir convention of daemontools, runit and s6: each file is a variable
+// named after the file, and its value is the file's first line, with
+// trailing spaces and tabs removed and any NUL characters replaced by
+// newlines. Empty files are left out, as envdir removes their variables.
+// As only the first line is read, this doesn't suit secret volumes, whose
+// files can hold PEM keys and certificates; use SecretVolumeSource for
+// those. The values aren't treated as secrets.
+func EnvdirSource(dir string) (Source, error) {
+	files, err := readDirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	values := make(MapSource, len(files))
+	for name, data := range files {
+		if len(data) == 0 || strings.Contains(name, "=") {
+			continue
+		}
+		line, _, _ := bytes.Cut(data, []byte("\n"))
+		line = bytes.TrimRight(line, " \t")
+		values[name] = string(bytes.ReplaceAll(line, []byte{0}, []byte("\n")))
+	}
+	return namedSource{values, "envdir:" + dir}, nil
+}
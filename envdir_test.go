@@ -0,0 +1,50 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvdirSource(t *testing.T) {
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"APP_PORT":  "8080\n",
+		"APP_HOST":  "example.com \t\nignored\n",
+		"APP_MOTD":  "line one\x00line two",
+		"APP_EMPTY": "",
+		"APP_BLANK": "\n",
+		".hidden":   "x",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src, err := EnvdirSource(dir)
+	if err != nil {
+		t.Fatalf("EnvdirSource returned an error: %v", err)
+	}
+	want := map[string]string{"APP_PORT": "8080", "APP_HOST": "example.com", "APP_MOTD": "line one\nline two", "APP_BLANK": ""}
+	for name, value := range want {
+		if got, ok := src.Lookup(name); !ok || got != value {
+			t.Errorf("%v was %q, %v, want %q.", name, got, ok, value)
+		}
+	}
+	for _, name := range []string{"APP_EMPTY", ".hidden"} {
+		if _, ok := src.Lookup(name); ok {
+			t.Errorf("%v was in the source.", name)
+		}
+	}
+
+	if _, err := EnvdirSource(filepath.Join(dir, "missing")); err == nil {
+		t.Error("EnvdirSource didn't return an error for a missing directory.")
+	}
+}
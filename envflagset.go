@@ -0,0 +1,61 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// An EnvFlagSet is a FlagSet which overrides its unset flags from the
+// environment whenever it parses arguments.
+type EnvFlagSet struct {
+	*flag.FlagSet
+
+	// Prefix and Options are passed to Override.
+	Prefix  string
+	Options []Option
+
+	// Report records the overrides applied by the last call to Parse.
+	Report Report
+}
+
+// NewEnvFlagSet returns a new, empty EnvFlagSet with the specified name,
+// error handling property, prefix, and options. Its usage message notes
+// which flags are overridden, using PrintDefaults.
+func NewEnvFlagSet(name string, errorHandling flag.ErrorHandling, prefix string, opts ...Option) *EnvFlagSet {
+	e := &EnvFlagSet{
+		FlagSet: flag.NewFlagSet(name, errorHandling),
+		Prefix:  prefix,
+		Options: opts,
+	}
+	e.Usage = func() { Usage(e.FlagSet, e.Prefix, e.Options...)() }
+	return e
+}
+
+// Parse parses flag definitions from the argument list, which should not
+// include the command name, then overrides the flags which weren't set.
+// Errors from overriding are handled according to the EnvFlagSet's error
+// handling property, like errors from parsing.
+func (e *EnvFlagSet) Parse(arguments []string) error {
+	if err := e.FlagSet.Parse(arguments); err != nil {
+		return err
+	}
+	opts := append(append([]Option{}, e.Options...), WithReport(&e.Report))
+	err := Override(e.FlagSet, e.Prefix, opts...)
+	if err == nil {
+		return nil
+	}
+	switch e.ErrorHandling() {
+	case flag.ExitOnError:
+		fmt.Fprintln(e.Output(), err)
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
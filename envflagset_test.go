@@ -0,0 +1,57 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestEnvFlagSet(t *testing.T) {
+
+	source := MapSource{"APP_POWERLEVEL": "9000", "APP_NAME": "fromenv"}
+	e := NewEnvFlagSet("scanner", flag.ContinueOnError, "APP_", WithSource(source))
+	power := e.Int("powerlevel", 0, "power level")
+	name := e.String("name", "default", "")
+
+	if err := e.Parse([]string{"-name", "fromargs"}); err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if *power != 9000 || *name != "fromargs" {
+		t.Errorf("flags were %v and %q, want 9000 and \"fromargs\".", *power, *name)
+	}
+	if len(e.Report.Overridden) != 1 || e.Report.Overridden[0].Var != "APP_POWERLEVEL" {
+		t.Errorf("Report was %+v.", e.Report)
+	}
+
+	var b strings.Builder
+	e.SetOutput(&b)
+	e.Usage()
+	if !strings.Contains(b.String(), "(overridden by environment variable APP_POWERLEVEL)") {
+		t.Errorf("Usage didn't note the override:\n%v", b.String())
+	}
+}
+
+func TestEnvFlagSetErrors(t *testing.T) {
+
+	source := MapSource{"APP_POWERLEVEL": "lots"}
+
+	e := NewEnvFlagSet("scanner", flag.ContinueOnError, "APP_", WithSource(source))
+	e.Int("powerlevel", 0, "")
+	if err := e.Parse(nil); err == nil {
+		t.Error("Parse didn't return the error from overriding.")
+	}
+
+	e = NewEnvFlagSet("scanner", flag.PanicOnError, "APP_", WithSource(source))
+	e.Int("powerlevel", 0, "")
+	defer func() {
+		if recover() == nil {
+			t.Error("Parse didn't panic with PanicOnError.")
+		}
+	}()
+	e.Parse(nil)
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ConfigureCmd appends the variables for the flags in fs called names to
+// the environment of cmd, using the names and values Environ gives them, so
+// that a supervisor can launch workers with the part of its configuration
+// they share. If cmd.Env is nil, meaning the command inherits the current
+// process's environment, it is started from os.Environ so nothing is lost.
+// Secret flags are left out unless WithSecretsIncluded is given. It returns
+// an error, leaving cmd alone, if any of the names isn't a defined flag.
+func ConfigureCmd(cmd *exec.Cmd, fs *flag.FlagSet, prefix string, names []string, opts ...Option) error {
+
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unable to configure command: no flag called %v", name)
+		}
+		allowed[name] = true
+	}
+
+	vars := newOptions(opts).environ(fs, prefix, func(f *flag.Flag) bool { return allowed[f.Name] })
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, vars...)
+	return nil
+}
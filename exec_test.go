@@ -0,0 +1,47 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestConfigureCmd(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	fs.String("greeting", "hello", "")
+	var token string
+	Bind(fs, &token, "token", "s3cret", "", BindOpts{Secret: true})
+
+	cmd := exec.Command("worker")
+	cmd.Env = []string{"HOME=/home/worker"}
+	if err := ConfigureCmd(cmd, fs, "APP_", []string{"port", "token"}); err != nil {
+		t.Fatalf("ConfigureCmd returned an error: %v", err)
+	}
+	want := []string{"HOME=/home/worker", "APP_PORT=80"}
+	if !reflect.DeepEqual(cmd.Env, want) {
+		t.Errorf("environment was %q, want %q.", cmd.Env, want)
+	}
+
+	cmd = exec.Command("worker")
+	if err := ConfigureCmd(cmd, fs, "APP_", []string{"token"}, WithSecretsIncluded()); err != nil {
+		t.Fatalf("ConfigureCmd returned an error: %v", err)
+	}
+	inherited := len(os.Environ())
+	if len(cmd.Env) != inherited+1 || cmd.Env[inherited] != "APP_TOKEN=s3cret" {
+		t.Errorf("environment ended with %q, want the inherited one and APP_TOKEN.", cmd.Env[inherited:])
+	}
+
+	cmd = exec.Command("worker")
+	if err := ConfigureCmd(cmd, fs, "APP_", []string{"colour"}); err == nil || cmd.Env != nil {
+		t.Errorf("ConfigureCmd returned %v for an undefined flag, and set the environment to %q.", err, cmd.Env)
+	}
+}
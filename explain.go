@@ -0,0 +1,79 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+)
+
+// An Explanation describes where the current value of a flag came from.
+type Explanation struct {
+	Flag   string // The name of the flag.
+	Value  string // The flag's current value, redacted for secret flags and values.
+	Origin string // One of the Origin constants.
+	Var    string // The variable the value came from, for overridden flags.
+
+	// Lookups holds the variables checked for the flag, in order.
+	Lookups []TraceLookup
+	// Reason says why none of the variables applied, if none did.
+	Reason string
+}
+
+// Explain describes where the current value of the flag called name came
+// from, for commands like "mytool config explain port". If it wasn't
+// overridden, the explanation lists the variables which were checked and
+// says why none of them applied. Explain looks the variables up again, so
+// it should be given the same source and options as Override, after
+// Override has been called. Values which Override treats as secrets, like
+// those decrypted by WithKMSDecrypt, are redacted unless WithSecretsIncluded
+// is given. It returns an error if fs has no such flag.
+func Explain(fs *flag.FlagSet, prefix, name string, opts ...Option) (Explanation, error) {
+
+	f := fs.Lookup(name)
+	if f == nil {
+		return Explanation{}, fmt.Errorf("no flag called %v", name)
+	}
+	o := newOptions(opts)
+	o.snapshotEnviron(fs, prefix)
+
+	e := Explanation{Flag: f.Name, Value: f.Value.String(), Origin: OriginDefault}
+	if isSecret(f) && !o.includeSecrets {
+		e.Value = redacted
+	}
+	set := setFlags(fs)[f.Name]
+
+	t := &Trace{Flag: f.Name}
+	p, found, err := o.resolve(fs, prefix, f, set, t)
+	if err != nil {
+		return Explanation{}, err
+	}
+	e.Lookups = t.Lookups
+	switch {
+	case found:
+		e.Origin, e.Var = OriginOverride, p.name
+		// The transforms say whether the value is a secret, because it
+		// was decrypted for example.
+		if err := o.transform(&p); err != nil {
+			return Explanation{}, err
+		}
+		if p.secret && !o.includeSecrets {
+			e.Value = redacted
+		}
+	case set:
+		// The variables weren't checked, as they wouldn't have been used,
+		// but they are worth listing.
+		if _, _, _, err := o.lookup(fs, prefix, f, t); err != nil {
+			return Explanation{}, err
+		}
+		e.Origin, e.Lookups, e.Reason = OriginCommandLine, t.Lookups, "the flag was set on the command line"
+	case t.Outcome == "not found":
+		e.Reason = "none of the variables were found"
+	default:
+		e.Reason = t.Outcome
+	}
+	return e, nil
+}
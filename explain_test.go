@@ -0,0 +1,72 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/base64"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("host", "", "")
+	fs.Int("port", 80, "")
+	fs.String("name", "app", "")
+	fs.Parse([]string{"-host", "example.com"})
+
+	source := MapSource{"APP_PORT": "8080", "APP_HOST": "ignored.example.com"}
+	opts := []Option{WithSource(source), WithScopes("SERVE_")}
+	if err := Override(fs, "APP_", opts...); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+
+	tests := []Explanation{
+		{Flag: "host", Value: "example.com", Origin: OriginCommandLine,
+			Lookups: []TraceLookup{{Var: "APP_SERVE_HOST"}, {Var: "APP_HOST", Found: true}},
+			Reason:  "the flag was set on the command line"},
+		{Flag: "port", Value: "8080", Origin: OriginOverride, Var: "APP_PORT",
+			Lookups: []TraceLookup{{Var: "APP_SERVE_PORT"}, {Var: "APP_PORT", Found: true}}},
+		{Flag: "name", Value: "app", Origin: OriginDefault,
+			Lookups: []TraceLookup{{Var: "APP_SERVE_NAME"}, {Var: "APP_NAME"}},
+			Reason:  "none of the variables were found"},
+	}
+	for _, want := range tests {
+		e, err := Explain(fs, "APP_", want.Flag, opts...)
+		if err != nil {
+			t.Fatalf("Explain returned an error: %v", err)
+		}
+		if !reflect.DeepEqual(e, want) {
+			t.Errorf("Explain returned %+v, want %+v.", e, want)
+		}
+	}
+
+	if _, err := Explain(fs, "APP_", "missing", opts...); err == nil {
+		t.Error("Explain didn't return an error for an undefined flag.")
+	}
+}
+
+func TestExplainDecryptedSecret(t *testing.T) {
+
+	decrypt := func(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+	source := MapSource{"APP_PASSWORD": kmsPrefix + base64.StdEncoding.EncodeToString([]byte("PLAINSECRET"))}
+	opts := []Option{WithSource(source), WithKMSDecrypt(decrypt)}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("password", "", "")
+	if err := Override(fs, "APP_", opts...); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	e, err := Explain(fs, "APP_", "password", opts...)
+	if err != nil {
+		t.Fatalf("Explain returned an error: %v", err)
+	}
+	if e.Value != redacted || e.Origin != OriginOverride {
+		t.Errorf("explanation was %+v, want the value redacted.", e)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+)
+
+// WithCommaDecimals makes values for float flags which use a comma as the
+// decimal separator, like 0,5, mean the same as 0.5, as is usual in much of
+// the world. Only values with a single comma and no point are changed, so
+// this suits deployments where commas are never written as thousands
+// separators: with it, 1,000 is one, not a thousand. Float flags are those
+// whose Value has a Get method, like the flag package's, returning a
+// float64 or float32.
+func WithCommaDecimals() Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, func(p *pending) error {
+			if isFloatFlag(p.flag) && strings.Count(p.value, ",") == 1 && !strings.Contains(p.value, ".") {
+				p.value = strings.Replace(p.value, ",", ".", 1)
+			}
+			return nil
+		})
+	}
+}
+
+// isFloatFlag reports whether f holds a float64 or float32.
+func isFloatFlag(f *flag.Flag) bool {
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	switch g.Get().(type) {
+	case float64, float32:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestWithCommaDecimals(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	ratio := fs.Float64("ratio", 0, "")
+	scale := fs.Float64("scale", 0, "")
+	names := fs.String("names", "", "")
+	env := MapSource{"APP_RATIO": "0,5", "APP_SCALE": "-1,25e3", "APP_NAMES": "a,b"}
+	if err := Override(fs, "APP_", WithSource(env), WithCommaDecimals()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *ratio != 0.5 || *scale != -1250 || *names != "a,b" {
+		t.Errorf("flags were %v, %v and %q.", *ratio, *scale, *names)
+	}
+
+	for _, value := range []string{"1,000,000", "1.000,5"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Float64("ratio", 0, "")
+		if err := Override(fs, "APP_", WithSource(MapSource{"APP_RATIO": value}), WithCommaDecimals()); err == nil {
+			t.Errorf("Override didn't return an error for %q.", value)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"reflect"
+)
+
+// WithoutFuncFlags makes Override leave flags defined with flag.Func
+// and flag.BoolFunc alone, so their functions are only ever called by
+// the flag package while parsing the command line.
+func WithoutFuncFlags() Option {
+	return func(o *options) { o.skipFuncFlags = true }
+}
+
+// isFuncFlag reports whether f was defined with Func or BoolFunc.
+// The types behind those flags are unexported, so this checks their names.
+func isFuncFlag(f *flag.Flag) bool {
+	t := reflect.TypeOf(f.Value)
+	if t.PkgPath() != "flag" {
+		return false
+	}
+	return t.Name() == "funcValue" || t.Name() == "boolFuncValue"
+}
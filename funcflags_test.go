@@ -0,0 +1,76 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOverrideFuncFlags(t *testing.T) {
+
+	source := MapSource{"APP_ADD": "a", "APP_ENABLE": "true", "APP_PARSED": "b"}
+
+	calls := make(map[string]int)
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Func("add", "", func(s string) error { calls["add"]++; return nil })
+	fs.BoolFunc("enable", "", func(s string) error { calls["enable"]++; return nil })
+	fs.Func("parsed", "", func(s string) error { calls["parsed"]++; return nil })
+	fs.Int("count", 0, "")
+	fs.Parse([]string{"-parsed", "c"})
+
+	for i := 0; i < 3; i++ {
+		if err := Override(fs, "APP_", WithSource(source)); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+	}
+	for name, want := range map[string]int{"add": 1, "enable": 1, "parsed": 1} {
+		if calls[name] != want {
+			t.Errorf("function flag %v was called %d times, want %d.", name, calls[name], want)
+		}
+	}
+	if !isSet(fs, "add") {
+		t.Error("function flag set by Override wasn't recorded as set.")
+	}
+	if isFuncFlag(fs.Lookup("count")) {
+		t.Error("an int flag was detected as a function flag.")
+	}
+
+	// Set function flags aren't called again when the environment takes
+	// precedence, either.
+	calls = make(map[string]int)
+	fs = flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Func("parsed", "", func(s string) error { calls["parsed"]++; return nil })
+	fs.Parse([]string{"-parsed", "c"})
+	if err := Override(fs, "APP_", WithSource(source), WithEnvPrecedence("parsed")); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	fs = flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Func("parsed", "", func(s string) error { calls["parsed"]++; return nil })
+	if err := Load(fs, Layers{Args([]string{"-parsed", "c"}), Env("APP_", WithSource(source))}); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if calls["parsed"] != 2 {
+		t.Errorf("set function flags were called %d times, want once each.", calls["parsed"])
+	}
+
+	calls = make(map[string]int)
+	fs = flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Func("add", "", func(s string) error { calls["add"]++; return nil })
+	if err := Override(fs, "APP_", WithSource(source), WithoutFuncFlags()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if calls["add"] != 0 {
+		t.Error("function flag was called despite WithoutFuncFlags.")
+	}
+}
+
+// isSet reports whether the flag called name has been set in fs.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
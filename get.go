@@ -0,0 +1,33 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Get returns the current value of the flag called name in fs as a T,
+// using the flag's Get method. All the flag types defined by the flag
+// package implement flag.Getter; for example, Get[time.Duration] works for
+// a flag defined with fs.Duration. It is an error if the flag is undefined,
+// doesn't implement flag.Getter, or holds a value of another type.
+func Get[T any](fs *flag.FlagSet, name string) (T, error) {
+	var zero T
+	f := fs.Lookup(name)
+	if f == nil {
+		return zero, fmt.Errorf("flag %v is not defined", name)
+	}
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return zero, fmt.Errorf("flag %v doesn't implement flag.Getter", name)
+	}
+	v, ok := g.Get().(T)
+	if !ok {
+		return zero, fmt.Errorf("flag %v holds a %T, not a %T", name, g.Get(), zero)
+	}
+	return v, nil
+}
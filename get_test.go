@@ -0,0 +1,43 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Duration("timeout", time.Second, "")
+	fs.Int("count", 1, "")
+	var l listValue
+	fs.Var(&l, "list", "")
+	if err := Override(fs, "APP_", WithSource(MapSource{"APP_TIMEOUT": "2m"})); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+
+	timeout, err := Get[time.Duration](fs, "timeout")
+	if err != nil || timeout != 2*time.Minute {
+		t.Errorf("Get returned %v, %v, want 2m0s.", timeout, err)
+	}
+	count, err := Get[int](fs, "count")
+	if err != nil || count != 1 {
+		t.Errorf("Get returned %v, %v, want 1.", count, err)
+	}
+
+	if _, err := Get[string](fs, "count"); err == nil {
+		t.Error("Getting an int flag as a string didn't cause an error.")
+	}
+	if _, err := Get[int](fs, "missing"); err == nil {
+		t.Error("Getting an undefined flag didn't cause an error.")
+	}
+	if _, err := Get[[]string](fs, "list"); err == nil {
+		t.Error("Getting a flag which isn't a flag.Getter didn't cause an error.")
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/cu-library/overridefromenv
 
-go 1.13
+go 1.24
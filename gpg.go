@@ -0,0 +1,69 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gpgCommand is the gpg executable used to decrypt files.
+var gpgCommand = "gpg"
+
+// GPGOptions controls how GPGFile runs gpg.
+type GPGOptions struct {
+	// Homedir is the gpg home directory holding the keyring.
+	// If it is empty, gpg's default is used.
+	Homedir string
+
+	// Passphrase, if not nil, is called to get the passphrase for a
+	// symmetrically encrypted file or a protected secret key, instead of
+	// asking gpg-agent.
+	Passphrase func() ([]byte, error)
+}
+
+// GPGFile returns a Source holding the variables in the GPG encrypted file
+// at path, which holds KEY=VALUE lines in the syntax of DotenvSource.
+// The file is decrypted by running gpg, which must be installed, so it
+// works with keyrings managed by tools like pass and gopass. The decrypted
+// values are redacted like those of flags bound with Secret.
+func GPGFile(path string, opts GPGOptions) (Source, error) {
+
+	args := []string{"--batch", "--quiet", "--decrypt"}
+	if opts.Homedir != "" {
+		args = append([]string{"--homedir", opts.Homedir}, args...)
+	}
+	var stdin []byte
+	if opts.Passphrase != nil {
+		passphrase, err := opts.Passphrase()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the passphrase for %v: %w", path, err)
+		}
+		args = append(args, "--pinentry-mode", "loopback", "--passphrase-fd", "0")
+		stdin = append(append([]byte{}, passphrase...), '\n')
+	}
+	args = append(args, path)
+
+	cmd := exec.Command(gpgCommand, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %v", err, msg)
+		}
+		return nil, fmt.Errorf("unable to decrypt %v with gpg: %w", path, err)
+	}
+
+	values, err := parseDotenv(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read decrypted %v: %w", path, err)
+	}
+	return secretSource{namedSource{values, "gpg:" + path}}, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeGPG replaces the gpg executable with a script which prints its
+// arguments and standard input as dotenv variables.
+func fakeGPG(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake gpg is a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "gpg")
+	err := os.WriteFile(script, []byte("#!/bin/sh\necho \"ARGS=\\\"$*\\\"\"\nread -r line\necho \"STDIN=$line\"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := gpgCommand
+	t.Cleanup(func() { gpgCommand = old })
+	gpgCommand = script
+}
+
+func TestGPGFile(t *testing.T) {
+
+	fakeGPG(t)
+
+	s, err := GPGFile("secrets.env.gpg", GPGOptions{})
+	if err != nil {
+		t.Fatalf("GPGFile returned an error: %v", err)
+	}
+	if v, _ := s.Lookup("ARGS"); v != "--batch --quiet --decrypt secrets.env.gpg" {
+		t.Errorf("gpg was run with %q.", v)
+	}
+	if !keySecret(s, "ARGS") {
+		t.Error("GPGFile didn't treat the decrypted values as secrets.")
+	}
+
+	s, err = GPGFile("secrets.env.gpg", GPGOptions{
+		Homedir:    "/keys",
+		Passphrase: func() ([]byte, error) { return []byte("correct horse"), nil },
+	})
+	if err != nil {
+		t.Fatalf("GPGFile returned an error: %v", err)
+	}
+	if v, _ := s.Lookup("ARGS"); v != "--homedir /keys --batch --quiet --decrypt --pinentry-mode loopback --passphrase-fd 0 secrets.env.gpg" {
+		t.Errorf("gpg was run with %q.", v)
+	}
+	if v, _ := s.Lookup("STDIN"); v != "correct horse" {
+		t.Errorf("gpg was given the passphrase %q.", v)
+	}
+
+	_, err = GPGFile("secrets.env.gpg", GPGOptions{
+		Passphrase: func() ([]byte, error) { return nil, errors.New("cancelled") },
+	})
+	if err == nil {
+		t.Error("GPGFile didn't return the error from the passphrase callback.")
+	}
+}
+
+func TestGPGFileError(t *testing.T) {
+
+	old := gpgCommand
+	defer func() { gpgCommand = old }()
+	gpgCommand = filepath.Join(t.TempDir(), "missing-gpg")
+
+	if _, err := GPGFile("secrets.env.gpg", GPGOptions{}); err == nil {
+		t.Error("GPGFile didn't return an error when gpg couldn't run.")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"strings"
+)
+
+// A group gives the flags matching its patterns their own prefix segment.
+type group struct {
+	name     string
+	patterns []func(string) bool
+}
+
+// WithGroup puts the flags matching any of the glob patterns, using the
+// syntax of path.Match, in the group called name, which gives them their
+// own segment after the prefix, for services with many subsystems. A
+// leading name followed by a hyphen is dropped from the flag names, so
+// with WithGroup("db", "db-*", "dsn"), the flags db-host and dsn are read
+// from APP_DB_HOST and APP_DB_DSN. A flag belongs to the first group it
+// matches. Scopes and profiles still come before the group's segment.
+func WithGroup(name string, patterns ...string) Option {
+	return func(o *options) {
+		g := group{name: name}
+		for _, pattern := range patterns {
+			g.patterns = append(g.patterns, globMatcher(pattern))
+		}
+		o.groups = append(o.groups, g)
+	}
+}
+
+// groupName returns the part of a variable name which follows the prefix
+// and scopes for the flag called name: the flag name itself, unless the
+// flag belongs to a group.
+func (o *options) groupName(name string) string {
+	for _, g := range o.groups {
+		for _, match := range g.patterns {
+			if match(name) {
+				return g.name + "_" + strings.TrimPrefix(name, g.name+"-")
+			}
+		}
+	}
+	return name
+}
@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestOverrideWithGroup(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db-host", "", "")
+	fs.String("dsn", "", "")
+	fs.String("cache-db-host", "", "")
+	fs.Int("port", 80, "")
+
+	got := CandidateNames(fs, "APP_", WithGroup("db", "db-*", "dsn"), WithGroup("cache", "cache-*"), WithScopes("SERVE_"))
+	want := map[string][]string{
+		"db-host":       {"APP_SERVE_DB_HOST", "APP_DB_HOST"},
+		"dsn":           {"APP_SERVE_DB_DSN", "APP_DB_DSN"},
+		"cache-db-host": {"APP_SERVE_CACHE_DB-HOST", "APP_CACHE_DB-HOST"},
+		"port":          {"APP_SERVE_PORT", "APP_PORT"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CandidateNames returned %v, want %v.", got, want)
+	}
+
+	source := MapSource{"APP_DB_HOST": "db.example.com"}
+	if err := Override(fs, "APP_", WithSource(source), WithGroup("db", "db-*")); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if host := fs.Lookup("db-host").Value.String(); host != "db.example.com" {
+		t.Errorf("flag db-host was %q, want db.example.com.", host)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+)
+
+// GetValuesFunc calls the GetValues method of the ConfigService defined in
+// proto/config.proto, returning the values whose names start with prefix.
+// With a client generated from the proto file, it looks like this:
+//
+//	func(ctx context.Context, prefix string) (map[string]string, error) {
+//		resp, err := client.GetValues(ctx, &configpb.GetValuesRequest{Prefix: prefix})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return resp.GetValues(), nil
+//	}
+type GetValuesFunc func(ctx context.Context, prefix string) (map[string]string, error)
+
+// GRPCSource returns a Source holding the values a ConfigService returns
+// for the prefix, so an organization's own configuration service can set
+// unset flags.
+func GRPCSource(ctx context.Context, prefix string, getValues GetValuesFunc) (Source, error) {
+	return FetchSource(ctx, "grpc", func(ctx context.Context) (map[string]string, error) {
+		return getValues(ctx, prefix)
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestGRPCSource(t *testing.T) {
+
+	var asked string
+	getValues := func(ctx context.Context, prefix string) (map[string]string, error) {
+		asked = prefix
+		return map[string]string{"APP_PORT": "8080"}, nil
+	}
+	src, err := GRPCSource(context.Background(), "APP_", getValues)
+	if err != nil {
+		t.Fatalf("GRPCSource returned an error: %v", err)
+	}
+	if asked != "APP_" {
+		t.Errorf("GetValues was called with prefix %q, want APP_.", asked)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	var r Report
+	if err := Override(fs, "APP_", WithSource(src), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || r.Overridden[0].Source != "grpc" {
+		t.Errorf("flag port was %v, from source %q.", *port, r.Overridden[0].Source)
+	}
+
+	unavailable := errors.New("unavailable")
+	_, err = GRPCSource(context.Background(), "APP_", func(context.Context, string) (map[string]string, error) {
+		return nil, unavailable
+	})
+	if !errors.Is(err, unavailable) {
+		t.Errorf("GRPCSource returned %v, want an error wrapping the one from GetValues.", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"os"
+	"strings"
+)
+
+// hostname returns the name of the host. Tests replace it.
+var hostname = os.Hostname
+
+// WithHostScope makes variables scoped to the host take precedence over
+// the generic ones, so one env file can be shared by a fleet of machines
+// while a few of them get different values. On the host web-01.example.com,
+// the flag port is read from APP_WEB_01_PORT, if it exists, before APP_PORT.
+// The scope is the host name up to its first dot, in upper case, with
+// anything other than letters and digits replaced by underscores. It takes
+// precedence over any scopes and profile. If the host name can't be found,
+// no host scope is used.
+func WithHostScope() Option {
+	return func(o *options) {
+		name, err := hostname()
+		if err != nil || name == "" {
+			o.hostScope = ""
+			return
+		}
+		name, _, _ = strings.Cut(name, ".")
+		o.hostScope = strings.Map(hostRune, strings.ToUpper(name)) + "_"
+	}
+}
+
+// hostRune replaces the runes which don't belong in a host scope.
+func hostRune(r rune) rune {
+	if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return r
+	}
+	return '_'
+}
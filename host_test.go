@@ -0,0 +1,34 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestOverrideWithHostScope(t *testing.T) {
+
+	defer func(h func() (string, error)) { hostname = h }(hostname)
+	hostname = func() (string, error) { return "web-01.example.com", nil }
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	got := CandidateNames(fs, "APP_", WithScopes("SERVE_"), WithHostScope())
+	want := map[string][]string{"port": {"APP_WEB_01_PORT", "APP_SERVE_PORT", "APP_PORT"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CandidateNames returned %v, want %v.", got, want)
+	}
+
+	hostname = func() (string, error) { return "", errors.New("no host name") }
+	got = CandidateNames(fs, "APP_", WithHostScope())
+	want = map[string][]string{"port": {"APP_PORT"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CandidateNames returned %v without a host name, want %v.", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// HTTPOptions says where HTTPSource fetches its document, and how.
+type HTTPOptions struct {
+	URL string
+
+	// CertFile and KeyFile are the PEM encoded client certificate and key
+	// presented to servers which require mutual TLS.
+	CertFile, KeyFile string
+
+	// CAFile holds PEM encoded certificates trusted to sign the server's
+	// certificate in place of the system's, for internal services.
+	CAFile string
+
+	// ServerName is the name the server's certificate is checked against,
+	// when it differs from the host in URL.
+	ServerName string
+
+	// Proxy is the URL of the proxy requests are sent through, like
+	// http://proxy.internal:3128. By default, the proxy is taken from the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables, as described by
+	// http.ProxyFromEnvironment.
+	Proxy string
+
+	// Header holds extra headers to send, like an API key.
+	Header http.Header
+
+	// Token, if it isn't nil, is called before each request for a token
+	// sent in an Authorization: Bearer header, so that short lived tokens,
+	// like OIDC service tokens, can be refreshed.
+	Token func(ctx context.Context) (string, error)
+
+	// Cache, if it isn't nil, keeps the document between calls to
+	// HTTPSource, so that a program which polls for changes makes
+	// conditional requests and doesn't download it again unless it changed.
+	Cache *HTTPCache
+
+	// Client is used in place of http.DefaultClient. It can't be combined
+	// with the TLS fields or Proxy, which are for building a client.
+	Client *http.Client
+}
+
+// HTTPSource returns a Source holding the values in the JSON document at
+// opts.URL, an object keyed by variable name, so that a simple
+// configuration service can set unset flags. Values are read like those
+// given to WithJSONVar, so numbers and booleans can be written as they
+// are.
+func HTTPSource(ctx context.Context, opts HTTPOptions) (Source, error) {
+
+	client, err := opts.client()
+	if err != nil {
+		return nil, err
+	}
+	return FetchSource(ctx, "http", func(ctx context.Context) (map[string]string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		for name, values := range opts.Header {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+		if opts.Token != nil {
+			token, err := opts.Token(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get token: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if opts.Cache != nil {
+			return opts.Cache.fetch(client, req)
+		}
+		var document json.RawMessage
+		if err := getJSON(client, req, &document); err != nil {
+			return nil, err
+		}
+		return jsonValues(document)
+	})
+}
+
+// An HTTPCache holds the last document fetched by HTTPSource, along with
+// its ETag and Last-Modified headers, which are sent back to the server in
+// If-None-Match and If-Modified-Since headers. When the server answers 304
+// Not Modified, the cached values are used. Its zero value is empty and
+// ready to use, and it is safe for concurrent use.
+type HTTPCache struct {
+	mu           sync.Mutex
+	etag         string
+	lastModified string
+	values       map[string]string
+	notModified  bool
+}
+
+// NotModified reports whether the server answered the last request made
+// with c with 304 Not Modified, so that a poller can skip reapplying the
+// configuration.
+func (c *HTTPCache) NotModified() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.notModified
+}
+
+// fetch sends req with client, conditionally if c holds a document, and
+// returns the values in the document.
+func (c *HTTPCache) fetch(client *http.Client, req *http.Request) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values != nil {
+		if c.etag != "" {
+			req.Header.Set("If-None-Match", c.etag)
+		}
+		if c.lastModified != "" {
+			req.Header.Set("If-Modified-Since", c.lastModified)
+		}
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	c.notModified = resp.StatusCode == http.StatusNotModified && c.values != nil
+	if c.notModified {
+		return c.values, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v returned %v", req.URL.Redacted(), resp.Status)
+	}
+	document, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the response from %v: %w", req.URL.Redacted(), err)
+	}
+	values, err := jsonValues(document)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the response from %v: %w", req.URL.Redacted(), err)
+	}
+	c.etag, c.lastModified, c.values = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), values
+	return values, nil
+}
+
+// client returns the client to fetch the document with.
+func (opts HTTPOptions) client() (*http.Client, error) {
+	if opts.CertFile == "" && opts.KeyFile == "" && opts.CAFile == "" && opts.ServerName == "" && opts.Proxy == "" {
+		return opts.Client, nil
+	}
+	if opts.Client != nil {
+		return nil, errors.New("HTTPOptions.Client can't be combined with the TLS options or Proxy")
+	}
+	config := &tls.Config{ServerName: opts.ServerName}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load certificate authorities: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %v", opts.CAFile)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || proxy.Host == "" {
+			// The proxy URL may hold credentials, so it's left out.
+			return nil, errors.New("invalid proxy URL")
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{Transport: transport}, nil
+}
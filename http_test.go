@@ -0,0 +1,233 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM writes a block of type kind holding der to a new file in dir.
+func writePEM(t *testing.T, dir, name, kind string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// clientCertificate writes a self-signed client certificate and its key to
+// dir, returning their paths and the certificate.
+func clientCertificate(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "worker"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client-key.pem", "PRIVATE KEY", keyDER), cert
+}
+
+func TestHTTPSource(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"APP_PORT": 7777, "APP_HOST": "example.com", "APP_NAME": null}`)
+	}))
+	defer server.Close()
+
+	src, err := HTTPSource(context.Background(), HTTPOptions{URL: server.URL})
+	if err != nil {
+		t.Fatalf("HTTPSource returned an error: %v", err)
+	}
+	for key, want := range map[string]string{"APP_PORT": "7777", "APP_HOST": "example.com"} {
+		if got, ok := src.Lookup(key); !ok || got != want {
+			t.Errorf("%v was %q, %v, want %q.", key, got, ok, want)
+		}
+	}
+	if _, ok := src.Lookup("APP_NAME"); ok {
+		t.Error("APP_NAME was in the source.")
+	}
+	if got := sourceName(src); got != "http" {
+		t.Errorf("source was called %q.", got)
+	}
+}
+
+func TestHTTPSourceMutualTLS(t *testing.T) {
+
+	dir := t.TempDir()
+	certFile, keyFile, cert := clientCertificate(t, dir)
+	clients := x509.NewCertPool()
+	clients.AddCert(cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"APP_PORT": "7777"}`)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clients}
+	server.StartTLS()
+	defer server.Close()
+	caFile := writePEM(t, dir, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	opts := HTTPOptions{URL: server.URL, CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "example.com"}
+	src, err := HTTPSource(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("HTTPSource returned an error: %v", err)
+	}
+	if v, ok := src.Lookup("APP_PORT"); !ok || v != "7777" {
+		t.Errorf("APP_PORT was %q, %v.", v, ok)
+	}
+
+	for name, opts := range map[string]HTTPOptions{
+		"no client certificate": {URL: server.URL, CAFile: caFile},
+		"untrusted server":      {URL: server.URL, CertFile: certFile, KeyFile: keyFile},
+		"wrong server name":     {URL: server.URL, CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "other.test"},
+		"missing key":           {URL: server.URL, CertFile: certFile, CAFile: caFile},
+		"invalid CA file":       {URL: server.URL, CAFile: keyFile},
+		"client and TLS":        {URL: server.URL, CAFile: caFile, Client: server.Client()},
+	} {
+		if _, err := HTTPSource(context.Background(), opts); err == nil {
+			t.Errorf("HTTPSource didn't return an error with %v.", name)
+		}
+	}
+}
+
+func TestHTTPSourceProxy(t *testing.T) {
+
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		fmt.Fprint(w, `{"APP_PORT": "7777"}`)
+	}))
+	defer proxy.Close()
+
+	src, err := HTTPSource(context.Background(), HTTPOptions{URL: "http://config.internal/app.json", Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("HTTPSource returned an error: %v", err)
+	}
+	if v, ok := src.Lookup("APP_PORT"); !ok || v != "7777" {
+		t.Errorf("APP_PORT was %q, %v.", v, ok)
+	}
+	if proxied != "http://config.internal/app.json" {
+		t.Errorf("proxy received a request for %q.", proxied)
+	}
+
+	for _, p := range []string{"not a url", "://"} {
+		if _, err := HTTPSource(context.Background(), HTTPOptions{URL: proxy.URL, Proxy: p}); err == nil {
+			t.Errorf("HTTPSource didn't return an error for the proxy %q.", p)
+		}
+	}
+}
+
+func TestHTTPSourceCache(t *testing.T) {
+
+	document := `{"APP_PORT": "7777"}`
+	etag := `"v1"`
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == "Mon, 01 Jan 2024 00:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		fmt.Fprint(w, document)
+	}))
+	defer server.Close()
+
+	cache := &HTTPCache{}
+	opts := HTTPOptions{URL: server.URL, Cache: cache}
+	lookup := func() string {
+		t.Helper()
+		src, err := HTTPSource(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("HTTPSource returned an error: %v", err)
+		}
+		v, _ := src.Lookup("APP_PORT")
+		return v
+	}
+
+	if v := lookup(); v != "7777" || cache.NotModified() {
+		t.Errorf("first fetch gave %q, not modified %v.", v, cache.NotModified())
+	}
+	if v := lookup(); v != "7777" || !cache.NotModified() {
+		t.Errorf("second fetch gave %q, not modified %v.", v, cache.NotModified())
+	}
+
+	document, etag = `{"APP_PORT": "8080"}`, `"v2"`
+	if v := lookup(); v != "8080" || cache.NotModified() {
+		t.Errorf("fetch after a change gave %q, not modified %v.", v, cache.NotModified())
+	}
+	if requests != 3 {
+		t.Errorf("server received %v requests, want 3.", requests)
+	}
+}
+
+func TestHTTPSourceHeaders(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" || r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"APP_PORT": "7777"}`)
+	}))
+	defer server.Close()
+
+	token := func(context.Context) (string, error) { return "token", nil }
+	opts := HTTPOptions{URL: server.URL, Header: http.Header{"x-api-key": {"key"}}, Token: token}
+	src, err := HTTPSource(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("HTTPSource returned an error: %v", err)
+	}
+	if v, ok := src.Lookup("APP_PORT"); !ok || v != "7777" {
+		t.Errorf("APP_PORT was %q, %v.", v, ok)
+	}
+
+	opts.Header = nil
+	if _, err := HTTPSource(context.Background(), opts); err == nil {
+		t.Error("HTTPSource didn't return an error when the server refused the request.")
+	}
+
+	expired := errors.New("token expired")
+	opts.Token = func(context.Context) (string, error) { return "", expired }
+	if _, err := HTTPSource(context.Background(), opts); !errors.Is(err, expired) {
+		t.Errorf("HTTPSource returned %v, want the token error.", err)
+	}
+}
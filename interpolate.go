@@ -0,0 +1,134 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// WithInterpolation lets values refer to the effective values of other
+// flags in the same FlagSet, by wrapping their names in braces.
+// With APP_METRICS_ADDR={host}:9100, the flag metrics-addr is set using the
+// value of the flag host, after host itself has been overridden.
+// A doubled brace, {{ or }}, stands for a single literal brace.
+// It is an error to refer to an undefined flag, or for overridden flags to
+// refer to each other in a cycle.
+func WithInterpolation() Option {
+	return func(o *options) { o.interpolate = true }
+}
+
+// interpolate replaces the references to flags in value with the current
+// values of those flags.
+func interpolate(fs *flag.FlagSet, value string) (string, error) {
+	var b strings.Builder
+	err := scanReferences(value, func(literal, name string) error {
+		b.WriteString(literal)
+		if name == "" {
+			return nil
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("reference to undefined flag %v", name)
+		}
+		b.WriteString(f.Value.String())
+		return nil
+	})
+	return b.String(), err
+}
+
+// scanReferences splits value into literal text and flag references,
+// calling fn with each piece of literal text and the name of the flag
+// referenced after it, which is empty at the end of value.
+func scanReferences(value string, fn func(literal, name string) error) error {
+	var literal strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '{' && strings.HasPrefix(value[i:], "{{"),
+			c == '}' && strings.HasPrefix(value[i:], "}}"):
+			literal.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(value[i:], '}')
+			if end == -1 {
+				return fmt.Errorf("unterminated reference at offset %d", i)
+			}
+			if err := fn(literal.String(), value[i+1:i+end]); err != nil {
+				return err
+			}
+			literal.Reset()
+			i += end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	return fn(literal.String(), "")
+}
+
+// references returns the names of the flags referred to in value.
+func references(value string) ([]string, error) {
+	var names []string
+	err := scanReferences(value, func(_, name string) error {
+		if name != "" {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// orderByReferences sorts overrides so that each flag comes after the
+// overridden flags its value refers to. Otherwise, the order is unchanged.
+func orderByReferences(fs *flag.FlagSet, overrides []pending) ([]pending, error) {
+
+	byName := make(map[string]pending)
+	for _, p := range overrides {
+		byName[p.flag.Name] = p
+	}
+
+	// A depth first search, tracking the flags on the current path to find cycles.
+	ordered := make([]pending, 0, len(overrides))
+	done := make(map[string]bool)
+	onPath := make(map[string]bool)
+	var visit func(p pending) error
+	visit = func(p pending) error {
+		name := p.flag.Name
+		if done[name] {
+			return nil
+		}
+		if onPath[name] {
+			return p.error(fmt.Errorf("reference cycle involving flag %v", name))
+		}
+		onPath[name] = true
+		refs, err := references(p.value)
+		if err != nil {
+			return p.error(err)
+		}
+		for _, ref := range refs {
+			if fs.Lookup(ref) == nil {
+				return p.error(fmt.Errorf("reference to undefined flag %v", ref))
+			}
+			if dep, ok := byName[ref]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		onPath[name] = false
+		done[name] = true
+		ordered = append(ordered, p)
+		return nil
+	}
+
+	for _, p := range overrides {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
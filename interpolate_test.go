@@ -0,0 +1,60 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestOverrideWithInterpolation(t *testing.T) {
+
+	source := MapSource{
+		"APP_METRICS-ADDR": "{host}:{metrics-port}",
+		"APP_HOST":         "example.com",
+		"APP_LABEL":        "{{literal}} {name}",
+	}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	addr := fs.String("metrics-addr", "", "")
+	fs.String("host", "localhost", "")
+	fs.Int("metrics-port", 9100, "")
+	fs.String("name", "scanner", "")
+	label := fs.String("label", "", "")
+
+	if err := Override(fs, "APP_", WithSource(source), WithInterpolation()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *addr != "example.com:9100" {
+		t.Errorf("interpolated flag was %q, want \"example.com:9100\".", *addr)
+	}
+	if *label != "{literal} scanner" {
+		t.Errorf("interpolated flag was %q, want \"{literal} scanner\".", *label)
+	}
+}
+
+func TestOverrideWithInterpolationErrors(t *testing.T) {
+
+	tests := []struct {
+		source MapSource
+		want   string
+	}{
+		{MapSource{"APP_A": "{b}", "APP_B": "{a}"}, "cycle"},
+		{MapSource{"APP_A": "{missing}"}, "undefined flag missing"},
+		{MapSource{"APP_A": "{b"}, "unterminated"},
+	}
+
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ExitOnError)
+		fs.String("a", "", "")
+		fs.String("b", "", "")
+		err := Override(fs, "APP_", WithSource(test.source), WithInterpolation())
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Override error was %v, want one mentioning %q.", err, test.want)
+		}
+	}
+}
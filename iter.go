@@ -0,0 +1,32 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"iter"
+)
+
+// Overrides returns an iterator over the flags Override would set, with the
+// values it would find for them, in the order Override would process them.
+// Nothing is set, so callers can log, filter, or apply the overrides on
+// their own terms. It accepts the same options as Override.
+// Values are not interpolated, split, or redacted.
+// If looking up the variables fails, the iterator yields nothing.
+func Overrides(fs *flag.FlagSet, prefix string, opts ...Option) iter.Seq2[*flag.Flag, string] {
+	return func(yield func(*flag.Flag, string) bool) {
+		o := newOptions(opts)
+		overrides, err := o.find(fs, prefix)
+		if err != nil {
+			return
+		}
+		for _, p := range overrides {
+			if !yield(p.flag, p.value) {
+				return
+			}
+		}
+	}
+}
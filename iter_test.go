@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOverrides(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	port := fs.Int("port", 80, "")
+	fs.String("name", "default", "")
+	fs.String("host", "localhost", "")
+	fs.Parse([]string{"-host", "example.com"})
+
+	source := MapSource{"APP_PORT": "8080", "APP_NAME": "fromenv", "APP_HOST": "ignored"}
+	var names, values []string
+	for f, value := range Overrides(fs, "APP_", WithSource(source)) {
+		names = append(names, f.Name)
+		values = append(values, value)
+	}
+	if len(names) != 2 || names[0] != "name" || names[1] != "port" || values[1] != "8080" {
+		t.Errorf("Overrides yielded %q, %q.", names, values)
+	}
+	if *port != 80 {
+		t.Error("Overrides set a flag.")
+	}
+
+	for f := range Overrides(fs, "APP_", WithSource(source)) {
+		if f.Name != "name" {
+			t.Errorf("Overrides yielded %v first.", f.Name)
+		}
+		break
+	}
+}
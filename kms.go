@@ -0,0 +1,51 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// kmsPrefix marks values holding KMS ciphertext.
+const kmsPrefix = "kms:"
+
+// WithKMSDecrypt decrypts values of the form kms:<base64 ciphertext> before
+// they are set, by passing the decoded ciphertext to decrypt. It is usually
+// a closure around an AWS KMS client:
+//
+//	WithKMSDecrypt(func(ciphertext []byte) ([]byte, error) {
+//		out, err := client.Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: ciphertext})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.Plaintext, nil
+//	})
+//
+// Other values are used as they are. Decrypted values are treated as secrets.
+// Values are decrypted before options which rewrite them, like
+// WithBareDurations and WithNormalization, are applied, wherever those are
+// given, so they work on the plaintext.
+func WithKMSDecrypt(decrypt func(ciphertext []byte) ([]byte, error)) Option {
+	return func(o *options) {
+		o.decrypts = append(o.decrypts, func(p *pending) error {
+			if !strings.HasPrefix(p.value, kmsPrefix) {
+				return nil
+			}
+			ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(p.value, kmsPrefix))
+			if err != nil {
+				return fmt.Errorf("invalid KMS ciphertext: %w", err)
+			}
+			plaintext, err := decrypt(ciphertext)
+			if err != nil {
+				return fmt.Errorf("unable to decrypt KMS ciphertext: %w", err)
+			}
+			p.value, p.secret = string(plaintext), true
+			return nil
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestOverrideWithKMSDecrypt(t *testing.T) {
+
+	decrypt := func(ciphertext []byte) ([]byte, error) {
+		if !bytes.HasPrefix(ciphertext, []byte("wrapped:")) {
+			return nil, errors.New("InvalidCiphertextException")
+		}
+		return bytes.TrimPrefix(ciphertext, []byte("wrapped:")), nil
+	}
+	source := MapSource{
+		"APP_PASSWORD": kmsPrefix + base64.StdEncoding.EncodeToString([]byte("wrapped:hunter2")),
+		"APP_NAME":     "plain",
+	}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	password := fs.String("password", "", "")
+	name := fs.String("name", "", "")
+	var r Report
+	if err := Override(fs, "APP_", WithSource(source), WithKMSDecrypt(decrypt), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *password != "hunter2" || *name != "plain" {
+		t.Errorf("flags were %q and %q.", *password, *name)
+	}
+	if r.Overridden[1].Flag != "password" || r.Overridden[1].Value != redacted {
+		t.Errorf("decrypted value was reported as %+v.", r.Overridden[1])
+	}
+
+	for _, value := range []string{kmsPrefix + "!!!", kmsPrefix + base64.StdEncoding.EncodeToString([]byte("bad"))} {
+		fs := flag.NewFlagSet("test", flag.ExitOnError)
+		fs.String("password", "", "")
+		err := Override(fs, "APP_", WithSource(MapSource{"APP_PASSWORD": value}), WithKMSDecrypt(decrypt))
+		if err == nil || !strings.Contains(err.Error(), "KMS") {
+			t.Errorf("Override error was %v, want one about KMS.", err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// serviceAccountDir is where Kubernetes mounts a pod's service account.
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesOptions says which ConfigMap or Secret KubernetesSource reads,
+// and how it reaches the API server. The zero values of the connection
+// fields use the pod's service account, for programs running in a cluster.
+type KubernetesOptions struct {
+	Name      string // The name of the ConfigMap or Secret.
+	Namespace string // Defaults to the namespace of the service account.
+	Secret    bool   // Read a Secret rather than a ConfigMap.
+
+	// Prefix is put before each data key, which is then upper cased, like
+	// the flag names in VarName, to give the variable names. With the
+	// prefix APP_, the data key port is read for the flag port.
+	Prefix string
+
+	Host   string       // The API server's URL, from KUBERNETES_SERVICE_HOST by default.
+	Token  string       // The bearer token, the service account's by default.
+	Client *http.Client // Trusts the service account's CA certificate by default.
+}
+
+// KubernetesSource returns a Source holding the data of a ConfigMap or a
+// Secret, read from the Kubernetes API server, for values which aren't
+// mounted as files or injected as variables. It uses the REST API directly,
+// rather than client-go, and in a pod needs no connection options. The
+// data of a Secret is redacted like the values of flags bound with Secret.
+func KubernetesSource(ctx context.Context, opts KubernetesOptions) (Source, error) {
+
+	kind, fetch := "configmaps", FetchSource
+	if opts.Secret {
+		kind, fetch = "secrets", fetchSecretSource
+	}
+	return fetch(ctx, "kubernetes:"+kind+"/"+opts.Name, func(ctx context.Context) (map[string]string, error) {
+		if err := opts.inCluster(); err != nil {
+			return nil, err
+		}
+		u := fmt.Sprintf("%v/api/v1/namespaces/%v/%v/%v", strings.TrimSuffix(opts.Host, "/"),
+			url.PathEscape(opts.Namespace), kind, url.PathEscape(opts.Name))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if opts.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+opts.Token)
+		}
+		var object struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := getJSON(opts.Client, req, &object); err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]string, len(object.Data))
+		for key, value := range object.Data {
+			if opts.Secret {
+				decoded, err := base64.StdEncoding.DecodeString(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid data for key %v: %w", key, err)
+				}
+				value = string(decoded)
+			}
+			values[VarName(opts.Prefix, key)] = value
+		}
+		return values, nil
+	})
+}
+
+// inCluster fills in the connection fields which aren't set from the pod's
+// environment and service account.
+func (opts *KubernetesOptions) inCluster() error {
+	if opts.Host == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return errors.New("not running in a cluster, and no API server was given")
+		}
+		opts.Host = "https://" + net.JoinHostPort(host, port)
+	}
+	if opts.Namespace == "" {
+		namespace, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return fmt.Errorf("unable to find the namespace: %w", err)
+		}
+		opts.Namespace = strings.TrimSpace(string(namespace))
+	}
+	if opts.Token == "" {
+		token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+		if err != nil {
+			return fmt.Errorf("unable to read the service account token: %w", err)
+		}
+		opts.Token = strings.TrimSpace(string(token))
+	}
+	if opts.Client == nil {
+		ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+		if err != nil {
+			return fmt.Errorf("unable to read the service account CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return errors.New("no certificates in the service account CA certificate")
+		}
+		opts.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"encoding/pem"
+	"flag"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKubernetesSource(t *testing.T) {
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/prod/configmaps/scanner":
+			w.Write([]byte(`{"data": {"port": "8080", "log-level": "debug"}}`))
+		case "/api/v1/namespaces/prod/secrets/scanner":
+			w.Write([]byte(`{"data": {"token": "aHVudGVyMg=="}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// Run as though in a pod, with a service account.
+	defer func(dir string) { serviceAccountDir = dir }(serviceAccountDir)
+	serviceAccountDir = t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	files := map[string][]byte{"namespace": []byte("prod\n"), "token": []byte("token"), "ca.crt": ca}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(serviceAccountDir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, _ := url.Parse(srv.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	portFlag := fs.Int("port", 80, "")
+	level := fs.String("log-level", "info", "")
+	token := fs.String("token", "", "")
+
+	for _, secret := range []bool{false, true} {
+		src, err := KubernetesSource(context.Background(), KubernetesOptions{Name: "scanner", Secret: secret, Prefix: "APP_"})
+		if err != nil {
+			t.Fatalf("KubernetesSource returned an error: %v", err)
+		}
+		var r Report
+		if err := Override(fs, "APP_", WithSource(src), WithReport(&r)); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+		// Only the data of the Secret is redacted.
+		for _, p := range r.Overridden {
+			if p.Secret != secret {
+				t.Errorf("flag %v was reported as %+v.", p.Flag, p)
+			}
+		}
+	}
+	if *portFlag != 8080 || *level != "debug" || *token != "hunter2" {
+		t.Errorf("flags were port=%v, log-level=%q and token=%q.", *portFlag, *level, *token)
+	}
+
+	_, err := KubernetesSource(context.Background(), KubernetesOptions{Name: "missing"})
+	if err == nil {
+		t.Error("KubernetesSource didn't return an error for a missing ConfigMap.")
+	}
+	_, err = KubernetesSource(context.Background(), KubernetesOptions{Name: "scanner", Token: "wrong"})
+	if err == nil {
+		t.Error("KubernetesSource didn't return an error when it wasn't authorized.")
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// A Layer is one level of configuration in a call to Load.
+type Layer interface {
+	// apply sets the flags in fs which are not in done,
+	// and adds the names of the flags it sets to done.
+	apply(fs *flag.FlagSet, done map[string]bool) error
+}
+
+// Layers lists configuration layers from lowest to highest precedence.
+type Layers []Layer
+
+// Load configures fs from each of the layers, in one call.
+// Values from later layers take precedence over values from earlier ones,
+// so with the conventional order
+//
+//	Load(fs, Layers{Defaults, ConfigFile(path), Env(prefix), Args(os.Args[1:])})
+//
+// command line arguments beat the environment, which beats the config file,
+// which beats the flags' defaults.
+// The arguments in an Args layer are always parsed first, wherever the layer
+// is, so fs.Parsed and fs.Args behave as usual; layers above it then replace
+// the values it set.
+func Load(fs *flag.FlagSet, layers Layers) error {
+
+	for _, l := range layers {
+		if args, ok := l.(argsLayer); ok {
+			if err := fs.Parse(args); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Apply the layers from highest to lowest precedence, so each layer
+	// only needs to fill in the flags the layers above it didn't set.
+	done := make(map[string]bool)
+	for i := len(layers) - 1; i >= 0; i-- {
+		if err := layers[i].apply(fs, done); err != nil {
+			return err
+		}
+	}
+
+	// Check the required flags now that every layer has had a chance to set them.
+	set := setFlags(fs)
+	for _, f := range requiredFlags(fs) {
+		if !done[f.Name] && !set[f.Name] {
+			return fmt.Errorf("flag %v was not set by any layer: %w", f.Name, ErrRequired)
+		}
+	}
+	return nil
+}
+
+// Defaults is the layer holding the flags' default values.
+// It is always the lowest layer, whether or not it is listed,
+// but listing it makes the precedence of a Load call easier to read.
+var Defaults Layer = defaultsLayer{}
+
+// defaultsLayer leaves the flags' default values in place.
+type defaultsLayer struct{}
+
+func (defaultsLayer) apply(fs *flag.FlagSet, done map[string]bool) error {
+	return nil
+}
+
+// Args returns a layer holding the flags set in the command line arguments.
+func Args(args []string) Layer {
+	return argsLayer(args)
+}
+
+// argsLayer holds command line arguments, which Load parses up front.
+type argsLayer []string
+
+func (argsLayer) apply(fs *flag.FlagSet, done map[string]bool) error {
+	fs.Visit(func(f *flag.Flag) { done[f.Name] = true })
+	return nil
+}
+
+// Env returns a layer holding the values of environment variables,
+// as found by Override with the given prefix and options.
+func Env(prefix string, opts ...Option) Layer {
+	return sourceLayer{prefix: prefix, opts: opts}
+}
+
+// ConfigFile returns a layer holding the values in the file at path.
+// The file uses the same syntax as DotenvSource, but its keys are flag
+// names, which are compared without regard to case. It is an error
+// for the file not to exist. Files encrypted by SOPS are decrypted, and
+// their values treated as secrets.
+func ConfigFile(path string) Layer {
+	return fileLayer(path)
+}
+
+// ConfigFiles returns a layer holding the values in the files at paths,
+// read as ConfigFile reads them, with later files taking precedence over
+// earlier ones, so a user's settings can be merged over the system's.
+// Files which don't exist are skipped, but other errors reading them
+// aren't. The Report records which file each value came from.
+func ConfigFiles(paths ...string) Layer {
+	return filesLayer(paths)
+}
+
+// filesLayer reads several config files when it is applied.
+type filesLayer []string
+
+func (paths filesLayer) apply(fs *flag.FlagSet, done map[string]bool) error {
+	for i := len(paths) - 1; i >= 0; i-- {
+		if _, err := os.Stat(paths[i]); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := fileLayer(paths[i]).apply(fs, done); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// systemConfigDir holds the system's configuration. Tests replace it.
+var systemConfigDir = "/etc"
+
+// AppConfig returns a layer holding the classic configuration of a
+// packaged program called app: the system's file, /etc/<app>/config, with
+// the user's file, <app>/config in the directory ConfigPaths puts it in
+// (usually ~/.config), merged over it by ConfigFiles. Either file may be
+// missing. It goes below the environment and the command line:
+//
+//	Load(fs, Layers{Defaults, AppConfig("scanner"), Env(PREFIX), Args(os.Args[1:])})
+//
+// On Windows, there is no system file.
+func AppConfig(app string) Layer {
+	var paths []string
+	if runtime.GOOS != "windows" {
+		paths = append(paths, filepath.Join(systemConfigDir, app, "config"))
+	}
+	if dir, ok := userConfigDir(); ok {
+		paths = append(paths, filepath.Join(dir, app, "config"))
+	}
+	return ConfigFiles(paths...)
+}
+
+// fileLayer reads a config file when it is applied.
+type fileLayer string
+
+func (path fileLayer) apply(fs *flag.FlagSet, done map[string]bool) error {
+	values, decrypted, err := readDotenvFile(string(path))
+	if err != nil {
+		return err
+	}
+	normalized := make(MapSource)
+	for k, v := range values {
+		normalized[strings.ToUpper(k)] = v
+	}
+	source := dotenvFileSource(normalized, "file:"+string(path), decrypted)
+	return sourceLayer{opts: []Option{WithSource(source)}}.apply(fs, done)
+}
+
+// sourceLayer applies Override.
+type sourceLayer struct {
+	prefix string
+	opts   []Option
+}
+
+func (l sourceLayer) apply(fs *flag.FlagSet, done map[string]bool) error {
+	var r Report
+	opts := append(append([]Option{}, l.opts...), WithReport(&r), withLayer(done))
+	if err := Override(fs, l.prefix, opts...); err != nil {
+		return err
+	}
+	for _, p := range r.Overridden {
+		done[p.Flag] = true
+	}
+	return nil
+}
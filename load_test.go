@@ -0,0 +1,135 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(path, []byte("a=file\nb=file\nC=file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LOADTEST_B", "env")
+	t.Setenv("LOADTEST_C", "env")
+	t.Setenv("LOADTEST_D", "env")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := fs.String("a", "default", "")
+	b := fs.String("b", "default", "")
+	c := fs.String("c", "default", "")
+	d := fs.String("d", "default", "")
+	e := fs.String("e", "default", "")
+
+	err = Load(fs, Layers{Defaults, ConfigFile(path), Env("LOADTEST_"), Args([]string{"-c", "args", "extra"})})
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	for name, got := range map[string]string{"a": *a, "b": *b, "c": *c, "d": *d, "e": *e} {
+		want := map[string]string{"a": "file", "b": "env", "c": "args", "d": "env", "e": "default"}[name]
+		if got != want {
+			t.Errorf("flag %v was %q, want %q.", name, got, want)
+		}
+	}
+	if !fs.Parsed() || fs.NArg() != 1 {
+		t.Error("The arguments weren't parsed.")
+	}
+}
+
+func TestLoadEnvAboveArgs(t *testing.T) {
+
+	t.Setenv("LOADTEST_A", "env")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := fs.String("a", "default", "")
+	b := fs.String("b", "default", "")
+
+	err := Load(fs, Layers{Args([]string{"-a", "args", "-b", "args"}), Env("LOADTEST_")})
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if *a != "env" || *b != "args" {
+		t.Errorf("flags were %q and %q, want \"env\" and \"args\".", *a, *b)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("a", "default", "")
+
+	if err := Load(fs, Layers{Args([]string{"-undefined"})}); err == nil {
+		t.Error("Load didn't return the error from parsing the arguments.")
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := Load(fs, Layers{ConfigFile(missing)}); err == nil {
+		t.Error("Load didn't return an error for a missing config file.")
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	system := filepath.Join(dir, "system")
+	user := filepath.Join(dir, "user")
+	os.WriteFile(system, []byte("a=system\nb=system\n"), 0600)
+	os.WriteFile(user, []byte("b=user\n"), 0600)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := fs.String("a", "default", "")
+	b := fs.String("b", "default", "")
+	c := fs.String("c", "default", "")
+	layers := Layers{Defaults, ConfigFiles(system, filepath.Join(dir, "missing"), user), Env("APP_", WithSource(MapSource{"APP_C": "env"}))}
+	if err := Load(fs, layers); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if *a != "system" || *b != "user" || *c != "env" {
+		t.Errorf("flags were %v, %v and %v.", *a, *b, *c)
+	}
+
+	if err := Load(fs, Layers{ConfigFiles(dir)}); err == nil {
+		t.Error("Load didn't return an error for a config file which is a directory.")
+	}
+}
+
+func TestAppConfig(t *testing.T) {
+
+	dir := t.TempDir()
+	defer func(old string) { systemConfigDir = old }(systemConfigDir)
+	systemConfigDir = filepath.Join(dir, "etc")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+	writeConfig(t, systemConfigDir, "scanner", "config", "a=system\nb=system\n")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	a := fs.String("a", "default", "")
+	b := fs.String("b", "default", "")
+	if err := Load(fs, Layers{Defaults, AppConfig("scanner"), Args([]string{"-a", "args"})}); err != nil {
+		t.Fatalf("Load returned an error without a user config: %v", err)
+	}
+	if *a != "args" || *b != "system" {
+		t.Errorf("flags were %v and %v.", *a, *b)
+	}
+
+	writeConfig(t, filepath.Join(dir, "home"), "scanner", "config", "b=user\n")
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	a = fs.String("a", "default", "")
+	b = fs.String("b", "default", "")
+	if err := Load(fs, Layers{Defaults, AppConfig("scanner")}); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if *a != "system" || *b != "user" {
+		t.Errorf("flags were %v and %v.", *a, *b)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"time"
+)
+
+// LocationVar defines a flag in fs with the specified name, default value,
+// and usage string, holding a time zone. The argument p points to a
+// *time.Location variable in which to store the value of the flag. Values
+// are IANA zone names, like America/Toronto, loaded with time.LoadLocation,
+// as well as UTC and Local, so a zone which doesn't exist is an error from
+// Parse or Override rather than a surprise at the first scheduled job. A
+// nil default means UTC.
+func LocationVar(fs *flag.FlagSet, p **time.Location, name string, value *time.Location, usage string) {
+	if value == nil {
+		value = time.UTC
+	}
+	*p = value
+	fs.Var(locationValue{p}, name, usage)
+}
+
+// Location defines a flag in fs holding a time zone, as LocationVar does,
+// and returns the address of a *time.Location variable holding its value.
+func Location(fs *flag.FlagSet, name string, value *time.Location, usage string) **time.Location {
+	p := new(*time.Location)
+	LocationVar(fs, p, name, value, usage)
+	return p
+}
+
+// locationValue is a flag.Value holding a time zone.
+type locationValue struct {
+	p **time.Location
+}
+
+// Set loads the zone called s.
+func (l locationValue) Set(s string) error {
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return err
+	}
+	*l.p = loc
+	return nil
+}
+
+// String returns the name of the zone.
+func (l locationValue) String() string {
+	if l.p == nil {
+		return ""
+	}
+	return (*l.p).String()
+}
+
+// Get returns the zone, so a locationValue is a flag.Getter.
+func (l locationValue) Get() any {
+	return *l.p
+}
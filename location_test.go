@@ -0,0 +1,50 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocation(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	zone := Location(fs, "zone", nil, "time zone for schedules")
+	var utc *time.Location
+	LocationVar(fs, &utc, "report-zone", time.UTC, "")
+	if got := fs.Lookup("zone").Value.String(); got != "UTC" {
+		t.Errorf("default zone was %q, want UTC.", got)
+	}
+
+	if err := Override(fs, "APP_", WithSource(MapSource{"APP_ZONE": "America/Toronto"})); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if (*zone).String() != "America/Toronto" || utc != time.UTC {
+		t.Errorf("zones were %v and %v.", *zone, utc)
+	}
+	if got, ok := fs.Lookup("zone").Value.(flag.Getter).Get().(*time.Location); !ok || got != *zone {
+		t.Errorf("Get returned %v, %v.", got, ok)
+	}
+
+	err := Override(fs, "APP_", WithSource(MapSource{"APP_REPORT-ZONE": "Mars/Olympus_Mons"}))
+	if err == nil || !strings.Contains(err.Error(), "report-zone") {
+		t.Errorf("Override returned %v for a zone which doesn't exist.", err)
+	}
+}
+
+func TestLocationNilDefault(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	zone := Location(fs, "zone", nil, "")
+	if *zone != time.UTC {
+		t.Errorf("default zone was %v, want time.UTC.", *zone)
+	}
+	// This panics with a nil *time.Location.
+	time.Now().In(*zone)
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// LogLevel defines a flag in fs with the specified name, default level, and
+// usage string, holding a log level, and returns the slog.LevelVar holding
+// its value, which can be given straight to a slog.HandlerOptions:
+//
+//	level := overridefromenv.LogLevel(flag.CommandLine, "log-level", slog.LevelInfo, "log level")
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+//
+// Values are the level names debug, info, warn and error, in any case and
+// with an optional offset like warn+2, as slog.Level parses them, or a
+// number like -4.
+func LogLevel(fs *flag.FlagSet, name string, value slog.Level, usage string) *slog.LevelVar {
+	v := new(slog.LevelVar)
+	v.Set(value)
+	fs.Var(levelValue{v}, name, usage)
+	return v
+}
+
+// levelValue is a flag.Value holding a log level.
+type levelValue struct {
+	v *slog.LevelVar
+}
+
+// Set parses s as a level name or number.
+func (l levelValue) Set(s string) error {
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		l.v.Set(slog.Level(n))
+		return nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return err
+	}
+	l.v.Set(level)
+	return nil
+}
+
+// String returns the name of the level.
+func (l levelValue) String() string {
+	if l.v == nil {
+		return slog.LevelInfo.String()
+	}
+	return l.v.Level().String()
+}
+
+// Get returns the level, so a levelValue is a flag.Getter.
+func (l levelValue) Get() any {
+	return l.v.Level()
+}
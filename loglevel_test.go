@@ -0,0 +1,45 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"log/slog"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+
+	for value, want := range map[string]slog.Level{
+		"debug":  slog.LevelDebug,
+		"INFO":   slog.LevelInfo,
+		"Warn":   slog.LevelWarn,
+		"error":  slog.LevelError,
+		"warn+2": slog.LevelWarn + 2,
+		"-8":     slog.Level(-8),
+		"12":     slog.Level(12),
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		level := LogLevel(fs, "log-level", slog.LevelInfo, "")
+		if err := Override(fs, "APP_", WithSource(MapSource{"APP_LOG-LEVEL": value})); err != nil {
+			t.Errorf("Override returned an error for %q: %v", value, err)
+			continue
+		}
+		if level.Level() != want {
+			t.Errorf("level for %q was %v, want %v.", value, level.Level(), want)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	level := LogLevel(fs, "log-level", slog.LevelWarn, "")
+	f := fs.Lookup("log-level")
+	if f.DefValue != "WARN" || f.Value.(flag.Getter).Get() != slog.LevelWarn {
+		t.Errorf("default was %q, %v.", f.DefValue, f.Value.(flag.Getter).Get())
+	}
+	if err := Override(fs, "APP_", WithSource(MapSource{"APP_LOG-LEVEL": "verbose"})); err == nil {
+		t.Errorf("Override didn't return an error for an unknown level, the level was %v.", level.Level())
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"path"
+	"regexp"
+)
+
+// WithMatch makes Override only consider flags whose names match one of the
+// glob patterns, using the syntax of path.Match, so that large generated
+// FlagSets can be scoped without listing every flag. WithMatch("db-*")
+// limits Override to the flags starting with db-. Given several times, a
+// flag matching any of the patterns is considered. Malformed patterns
+// match nothing.
+func WithMatch(patterns ...string) Option {
+	return func(o *options) {
+		for _, pattern := range patterns {
+			o.include = append(o.include, globMatcher(pattern))
+		}
+	}
+}
+
+// WithExcludeMatch makes Override leave alone the flags whose names match
+// any of the glob patterns, using the syntax of path.Match. Exclusions take
+// precedence over WithMatch. Malformed patterns match nothing.
+func WithExcludeMatch(patterns ...string) Option {
+	return func(o *options) {
+		for _, pattern := range patterns {
+			o.exclude = append(o.exclude, globMatcher(pattern))
+		}
+	}
+}
+
+// WithMatchRegexp is like WithMatch, but with a regular expression, which
+// can match any part of a flag's name.
+func WithMatchRegexp(re *regexp.Regexp) Option {
+	return func(o *options) { o.include = append(o.include, re.MatchString) }
+}
+
+// WithExcludeRegexp is like WithExcludeMatch, but with a regular expression,
+// which can match any part of a flag's name. WithExcludeRegexp(
+// regexp.MustCompile("^debug-")) leaves alone the flags starting with debug-.
+func WithExcludeRegexp(re *regexp.Regexp) Option {
+	return func(o *options) { o.exclude = append(o.exclude, re.MatchString) }
+}
+
+// globMatcher returns a function reporting whether a name matches pattern.
+func globMatcher(pattern string) func(string) bool {
+	return func(name string) bool {
+		matched, err := path.Match(pattern, name)
+		return err == nil && matched
+	}
+}
+
+// matches reports whether f passes the include and exclude patterns.
+func (o *options) matches(f *flag.Flag) bool {
+	for _, match := range o.exclude {
+		if match(f.Name) {
+			return false
+		}
+	}
+	for _, match := range o.include {
+		if match(f.Name) {
+			return true
+		}
+	}
+	return len(o.include) == 0
+}
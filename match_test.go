@@ -0,0 +1,49 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestOverrideWithMatch(t *testing.T) {
+
+	source := MapSource{"APP_DB-HOST": "db", "APP_DB-DEBUG": "true", "APP_DEBUG-SQL": "true", "APP_PORT": "8080"}
+	tests := []struct {
+		opts []Option
+		want []string
+	}{
+		{[]Option{WithMatch("db-*")}, []string{"db-debug", "db-host"}},
+		{[]Option{WithMatch("db-*", "port")}, []string{"db-debug", "db-host", "port"}},
+		{[]Option{WithExcludeMatch("*debug*")}, []string{"db-host", "port"}},
+		{[]Option{WithMatch("db-*"), WithExcludeMatch("*-debug")}, []string{"db-host"}},
+		{[]Option{WithExcludeRegexp(regexp.MustCompile("^debug-"))}, []string{"db-debug", "db-host", "port"}},
+		{[]Option{WithMatchRegexp(regexp.MustCompile("debug"))}, []string{"db-debug", "debug-sql"}},
+		{[]Option{WithMatch("[")}, nil},
+	}
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String("db-host", "", "")
+		fs.Bool("db-debug", false, "")
+		fs.Bool("debug-sql", false, "")
+		fs.Int("port", 80, "")
+
+		var r Report
+		if err := Override(fs, "APP_", append(test.opts, WithSource(source), WithReport(&r))...); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+		var got []string
+		for _, p := range r.Overridden {
+			got = append(got, p.Flag)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Override set %v, want %v.", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidName is wrapped by the error Override returns when a derived
+// variable name is invalid and the policy is InvalidNameError.
+var ErrInvalidName = errors.New("invalid environment variable name")
+
+// An InvalidNamePolicy decides what Override does with a flag whose derived
+// variable name contains characters which can't appear in an environment
+// variable name: spaces, control characters, '=', and non-ASCII characters.
+type InvalidNamePolicy int
+
+const (
+	// InvalidNameAllow looks up the derived name unchanged.
+	// This is the default, and means the flag can't be set from the
+	// process environment, although other sources may still have it.
+	InvalidNameAllow InvalidNamePolicy = iota
+
+	// InvalidNameError makes Override return an error wrapping ErrInvalidName.
+	InvalidNameError
+
+	// InvalidNameSkip leaves the flag alone.
+	InvalidNameSkip
+
+	// InvalidNameSanitize replaces each invalid character with an underscore,
+	// so the flag "max size" is read from PREFIX_MAX_SIZE.
+	InvalidNameSanitize
+)
+
+// WithInvalidNamePolicy sets the policy for flags whose derived variable
+// names are not valid environment variable names.
+func WithInvalidNamePolicy(p InvalidNamePolicy) Option {
+	return func(o *options) { o.invalidNames = p }
+}
+
+// validName reports whether name can be used as an environment variable name.
+func validName(name string) bool {
+	return strings.IndexFunc(name, invalidRune) == -1
+}
+
+// invalidRune reports whether r can't appear in an environment variable name.
+func invalidRune(r rune) bool {
+	return r <= ' ' || r == '=' || r >= 0x7f
+}
+
+// sanitizeName replaces the characters in name which can't appear in an
+// environment variable name with underscores.
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if invalidRune(r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// checkName applies the invalid name policy to name, the variable name
+// derived for flag. It returns the name to look up, or false if the flag
+// should be skipped.
+func (o *options) checkName(flag, name string) (string, bool, error) {
+	if validName(name) {
+		return name, true, nil
+	}
+	switch o.invalidNames {
+	case InvalidNameError:
+		return "", false, fmt.Errorf("flag %v maps to %q: %w", flag, name, ErrInvalidName)
+	case InvalidNameSkip:
+		return "", false, nil
+	case InvalidNameSanitize:
+		return sanitizeName(name), true, nil
+	}
+	return name, true, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"strings"
+)
+
+// bom is the byte order mark, which editors on Windows often put at the
+// start of files, and which sometimes survives copying and pasting.
+const bom = "\uFEFF"
+
+// WithNormalization passes every value through normalize before it is set,
+// so that values pasted from documents compare equal to the ones typed by
+// hand. The package doesn't depend on golang.org/x/text, so the usual
+// normalizer, Unicode NFC, is given by the program:
+//
+//	WithNormalization(norm.NFC.String)
+func WithNormalization(normalize func(string) string) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, func(p *pending) error {
+			p.value = normalize(p.value)
+			return nil
+		})
+	}
+}
+
+// WithStripBOM removes a byte order mark from the start of values, and
+// zero width spaces from their start and end, where they are invisible but
+// break comparisons.
+func WithStripBOM() Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, func(p *pending) error {
+			p.value = strings.TrimPrefix(p.value, bom)
+			p.value = strings.Trim(p.value, "\u200B")
+			return nil
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestWithNormalization(t *testing.T) {
+
+	// A stand in for norm.NFC.String, composing just one character.
+	nfc := strings.NewReplacer("e\u0301", "é").Replace
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	name := fs.String("name", "", "")
+	env := MapSource{"APP_NAME": "cafe\u0301"}
+	if err := Override(fs, "APP_", WithSource(env), WithNormalization(nfc)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *name != "café" {
+		t.Errorf("name was %q, want it composed.", *name)
+	}
+}
+
+func TestWithStripBOM(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	name := fs.String("name", "", "")
+	port := fs.Int("port", 0, "")
+	env := MapSource{"APP_NAME": "\uFEFFhello\u200B", "APP_PORT": "\u200B7777"}
+	if err := Override(fs, "APP_", WithSource(env), WithStripBOM()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *name != "hello" || *port != 7777 {
+		t.Errorf("flags were %q and %v.", *name, *port)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// opPrefix marks 1Password secret references.
+const opPrefix = "op://"
+
+// OnePasswordConnect is a 1Password Connect server, which resolves secret
+// references of the form op://vault/item/field, or
+// op://vault/item/section/field.
+type OnePasswordConnect struct {
+	URL    string       // The URL of the Connect server.
+	Token  string       // A Connect access token.
+	Client *http.Client // http.DefaultClient if nil.
+}
+
+// OnePasswordSource returns a Source holding the secrets referred to by
+// refs, which maps variable names to secret references, so no secrets need
+// to be kept in the environment at all:
+//
+//	OnePasswordSource(ctx, connect, map[string]string{"APP_TOKEN": "op://prod/scanner/token"})
+//
+// Every reference is resolved before OnePasswordSource returns, and an
+// error resolving any of them is returned. The values are redacted like
+// those of flags bound with Secret.
+func OnePasswordSource(ctx context.Context, c *OnePasswordConnect, refs map[string]string) (Source, error) {
+	return fetchSecretSource(ctx, "1password", func(ctx context.Context) (map[string]string, error) {
+		values := make(map[string]string, len(refs))
+		for name, ref := range refs {
+			value, err := c.Resolve(ctx, ref)
+			if err != nil {
+				return nil, fmt.Errorf("variable %v: %w", name, err)
+			}
+			values[name] = value
+		}
+		return values, nil
+	})
+}
+
+// WithOnePassword resolves values which are 1Password secret references,
+// like APP_TOKEN=op://prod/scanner/token, with the Connect server c before
+// they are set, so the environment only holds references. Other values are
+// used as they are. Resolved values are treated as secrets, and are
+// resolved before other options rewrite values. The references are resolved
+// with ctx, so a deadline on it keeps an unreachable Connect server from
+// holding up Override:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	err := Override(fs, "APP_", WithOnePassword(ctx, connect))
+func WithOnePassword(ctx context.Context, c *OnePasswordConnect) Option {
+	return func(o *options) {
+		o.decrypts = append(o.decrypts, func(p *pending) error {
+			if !strings.HasPrefix(p.value, opPrefix) {
+				return nil
+			}
+			value, err := c.Resolve(ctx, p.value)
+			if err != nil {
+				return err
+			}
+			p.value, p.secret = value, true
+			return nil
+		})
+	}
+}
+
+// Resolve returns the value of the field the secret reference ref refers
+// to. Vaults are found by name, items by title, and sections and fields
+// by label or ID.
+func (c *OnePasswordConnect) Resolve(ctx context.Context, ref string) (string, error) {
+
+	parts := strings.Split(strings.TrimPrefix(ref, opPrefix), "/")
+	if !strings.HasPrefix(ref, opPrefix) || len(parts) < 3 || len(parts) > 4 {
+		return "", fmt.Errorf("invalid secret reference %q", ref)
+	}
+	vaultName, itemName, fieldName := parts[0], parts[1], parts[len(parts)-1]
+	sectionName := ""
+	if len(parts) == 4 {
+		sectionName = parts[2]
+	}
+
+	type object struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	}
+	var vaults []object
+	if err := c.get(ctx, "/v1/vaults", fmt.Sprintf("name eq %q", vaultName), &vaults); err != nil {
+		return "", err
+	}
+	if len(vaults) == 0 {
+		return "", fmt.Errorf("no vault called %v", vaultName)
+	}
+	var items []object
+	if err := c.get(ctx, "/v1/vaults/"+url.PathEscape(vaults[0].ID)+"/items", fmt.Sprintf("title eq %q", itemName), &items); err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("no item called %v in vault %v", itemName, vaultName)
+	}
+
+	var item struct {
+		Sections []struct {
+			ID    string `json:"id"`
+			Label string `json:"label"`
+		} `json:"sections"`
+		Fields []struct {
+			ID      string `json:"id"`
+			Label   string `json:"label"`
+			Value   string `json:"value"`
+			Section *struct {
+				ID string `json:"id"`
+			} `json:"section"`
+		} `json:"fields"`
+	}
+	itemPath := "/v1/vaults/" + url.PathEscape(vaults[0].ID) + "/items/" + url.PathEscape(items[0].ID)
+	if err := c.get(ctx, itemPath, "", &item); err != nil {
+		return "", err
+	}
+	sectionID := ""
+	for _, s := range item.Sections {
+		if sectionName != "" && (s.Label == sectionName || s.ID == sectionName) {
+			sectionID = s.ID
+		}
+	}
+	for _, f := range item.Fields {
+		if f.Label != fieldName && f.ID != fieldName {
+			continue
+		}
+		if sectionName == "" || f.Section != nil && f.Section.ID == sectionID && sectionID != "" {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("no field called %v in item %v", fieldName, itemName)
+}
+
+// get fetches the JSON at path on the Connect server into v, with an
+// optional filter.
+func (c *OnePasswordConnect) get(ctx context.Context, path, filter string, v any) error {
+	u := strings.TrimSuffix(c.URL, "/") + path
+	if filter != "" {
+		u += "?" + url.Values{"filter": {filter}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	return getJSON(c.Client, req, v)
+}
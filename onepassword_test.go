@@ -0,0 +1,115 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// onePasswordServer fakes a Connect server with one vault holding one item.
+func onePasswordServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer connect" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		filter := r.URL.Query().Get("filter")
+		switch r.URL.Path {
+		case "/v1/vaults":
+			if filter == `name eq "prod"` {
+				w.Write([]byte(`[{"id": "v1", "name": "prod"}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case "/v1/vaults/v1/items":
+			if filter == `title eq "scanner"` {
+				w.Write([]byte(`[{"id": "i1", "title": "scanner"}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case "/v1/vaults/v1/items/i1":
+			w.Write([]byte(`{"sections": [{"id": "s1", "label": "database"}], "fields": [
+				{"id": "f1", "label": "token", "value": "hunter2"},
+				{"id": "f2", "label": "password", "value": "swordfish", "section": {"id": "s1"}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOnePassword(t *testing.T) {
+
+	srv := onePasswordServer(t)
+	c := &OnePasswordConnect{URL: srv.URL, Token: "connect"}
+
+	src, err := OnePasswordSource(context.Background(), c, map[string]string{"APP_TOKEN": "op://prod/scanner/token"})
+	if err != nil {
+		t.Fatalf("OnePasswordSource returned an error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	token := fs.String("token", "", "")
+	var sr Report
+	if err := Override(fs, "APP_", WithSource(src), WithReport(&sr)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *token != "hunter2" {
+		t.Errorf("flag token was %q, want hunter2.", *token)
+	}
+	if len(sr.Overridden) != 1 || sr.Overridden[0].Value != redacted {
+		t.Errorf("the token from the source was reported as %+v.", sr.Overridden)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	password := fs.String("password", "", "")
+	name := fs.String("name", "", "")
+	var r Report
+	source := MapSource{"APP_PASSWORD": "op://prod/scanner/database/password", "APP_NAME": "scanner"}
+	if err := Override(fs, "APP_", WithSource(source), WithOnePassword(context.Background(), c), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *password != "swordfish" || *name != "scanner" {
+		t.Errorf("flags were password=%q and name=%q.", *password, *name)
+	}
+	for _, p := range r.Overridden {
+		if p.Flag == "password" && p.Value != redacted {
+			t.Errorf("the resolved password was reported as %q.", p.Value)
+		}
+	}
+
+	for _, ref := range []string{"op://prod/scanner", "op://staging/scanner/token", "op://prod/other/token",
+		"op://prod/scanner/missing", "op://prod/scanner/other/password"} {
+		if _, err := c.Resolve(context.Background(), ref); err == nil {
+			t.Errorf("Resolve didn't return an error for %v.", ref)
+		}
+	}
+	source = MapSource{"APP_PASSWORD": "op://prod/scanner/missing"}
+	err = Override(fs, "APP_", WithSource(source), WithOnePassword(context.Background(), c))
+	if err == nil || !strings.Contains(err.Error(), "no field called missing") {
+		t.Errorf("Override returned %v for a missing field.", err)
+	}
+
+	// An unreachable server doesn't hold up Override past the deadline.
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() }))
+	defer hung.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	source = MapSource{"APP_PASSWORD": "op://prod/scanner/database/password"}
+	err = Override(fs, "APP_", WithSource(source), WithOnePassword(ctx, &OnePasswordConnect{URL: hung.URL}))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Override returned %v for an unreachable server, want the deadline's error.", err)
+	}
+}
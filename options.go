@@ -0,0 +1,213 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"io"
+)
+
+// An Option changes the behaviour of Override.
+type Option func(*options)
+
+// options holds the configuration built from a list of Options.
+type options struct {
+	source          Source
+	report          *Report
+	invalidNames    InvalidNamePolicy
+	namespace       bool
+	scopes          []string
+	interpolate     bool
+	defValues       bool
+	emptyAsUnset    bool
+	skipFuncFlags   bool
+	separators      map[string]string
+	envFirst        map[string]bool
+	layerDone       map[string]bool
+	filter          func(*flag.Flag) bool
+	snapshot        bool
+	decrypts        []transform // Run before the other transforms.
+	transforms      []transform
+	includeSecrets  bool
+	errorFormatter  ErrorFormatter
+	warnings        func(Warning)
+	trace           func(Trace)
+	traces          []*Trace
+	audit           io.Writer
+	boolPresence    bool
+	paths           map[string]bool
+	baseDir         string
+	annotations     bool
+	profileSelector string
+	profileScope    *string
+	hostScope       string
+	systemVars      bool
+	reserved        map[string]bool
+	include         []func(string) bool
+	exclude         []func(string) bool
+	groups          []group
+	composites      []composite
+	requireParsed   bool
+	workers         int
+	renamed         map[string][]string
+	maxLength       int
+	maxLengths      map[string]int
+	rejectControl   bool
+	controlAllowed  map[string]bool
+}
+
+// newOptions applies opts over the default configuration.
+func newOptions(opts []Option) *options {
+	o := &options{
+		source:     Environment,
+		separators: make(map[string]string),
+		envFirst:   make(map[string]bool),
+		paths:      make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.report.reset()
+	return o
+}
+
+// WithSource makes Override look up values in s instead of the environment.
+func WithSource(s Source) Option {
+	return func(o *options) { o.source = s }
+}
+
+// WithReport makes Override record the overrides it applies in r.
+// Any previous contents of r are discarded.
+func WithReport(r *Report) Option {
+	return func(o *options) { o.report = r }
+}
+
+// WithNamespace inserts the name of the FlagSet, followed by an underscore,
+// between the prefix and the flag name. The flag port in a FlagSet called
+// serve is then read from APP_SERVE_PORT rather than APP_PORT, so several
+// FlagSets can share one prefix without colliding.
+// FlagSets with empty names are not namespaced. Only the last element of
+// a name which is a path is used, so flag.CommandLine, which is named after
+// the program's path, like ./bin/app, is namespaced as APP, and runes other
+// than letters and digits are replaced with underscores, as with
+// WithHostScope.
+func WithNamespace() Option {
+	return func(o *options) { o.namespace = true }
+}
+
+// WithScopes adds scope segments, which are tried between the prefix and the
+// flag name before the prefix alone. With the prefix APP_ and the scope
+// SERVE_, the flag port is read from APP_SERVE_PORT if it exists, and from
+// APP_PORT if it doesn't, so shared settings can be given once while
+// command specific ones are given only to the FlagSet they belong to.
+// When there are several scopes, later ones take precedence.
+func WithScopes(scopes ...string) Option {
+	return func(o *options) { o.scopes = append(o.scopes, scopes...) }
+}
+
+// WithDefValues makes Override update the DefValue of each flag it sets,
+// and note the variable the value came from at the end of the flag's usage
+// message, so that -help shows the values the program will actually use.
+// The DefValue of a flag set to a secret is left alone, so the secret isn't
+// shown by -help or written by WriteJSONSchema and WriteCUE.
+func WithDefValues() Option {
+	return func(o *options) { o.defValues = true }
+}
+
+// WithEmptyAsUnset makes Override treat variables set to the empty string
+// as though they were not set at all, leaving the flag's value alone.
+// By default, an empty variable is passed to the flag's Set method like any
+// other value, which is an error for most non-string flags.
+func WithEmptyAsUnset() Option {
+	return func(o *options) { o.emptyAsUnset = true }
+}
+
+// WithBoolPresence makes an empty variable set a boolean flag to true, the
+// way -verbose does on the command line, so VERBOSE= is enough to turn a
+// flag on. Boolean flags are those whose Value has an IsBoolFlag method
+// which returns true. Other values are passed to the flag as usual, so
+// VERBOSE=false still turns it off. This takes precedence over
+// WithEmptyAsUnset for boolean flags.
+func WithBoolPresence() Option {
+	return func(o *options) { o.boolPresence = true }
+}
+
+// WithSeparator splits the values for the named flags on sep, and passes
+// each part to the flag's Set method in turn, the same way the flag would
+// receive repeated arguments on the command line. This is meant for flags
+// whose values accumulate, like lists.
+func WithSeparator(sep string, names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.separators[name] = sep
+		}
+	}
+}
+
+// WithEnvPrecedence makes the environment take precedence over the command
+// line for the named flags: they are overridden even if they have been set,
+// unless they were defined with flag.Func or flag.BoolFunc.
+// This suits values injected by a platform, like PORT, which should win over
+// arguments baked into a container's command. The Report records each flag
+// which was replaced, with its previous value.
+func WithEnvPrecedence(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.envFirst[name] = true
+		}
+	}
+}
+
+// An ErrorFormatter builds the error returned when a flag can't be set from
+// the variable envVar, which has the given value, because of err.
+type ErrorFormatter func(flagName, envVar, value string, err error) error
+
+// WithErrorFormatter makes Override build the errors for flags which
+// can't be set with format, so applications can translate or restructure
+// them. The value is passed to format as it is, even for secret flags, so
+// format is responsible for redacting it. Returning an error which wraps
+// err keeps errors.Is and errors.As working.
+func WithErrorFormatter(format ErrorFormatter) Option {
+	return func(o *options) { o.errorFormatter = format }
+}
+
+// WithWarnings makes Override call handle with each warning as it is found,
+// as well as recording it in the Report, so it can be logged right away.
+// Warnings are given for variables which are ignored: those which aren't
+// valid names under InvalidNameSkip, those which are empty under
+// WithEmptyAsUnset, and those which start with the prefix, if it isn't
+// empty, but don't correspond to any flag, as returned by Unused. Names
+// changed by InvalidNameSanitize are also warned about.
+//
+// Override only knows about the flags in the FlagSet it is given, so when
+// several FlagSets share a prefix, like those of subcommands given
+// WithScopes, the variables of the others are warned about as unused: with
+// the prefix APP_, overriding the root FlagSet warns about APP_SERVE_PORT,
+// which belongs to the serve command. Give each FlagSet its own prefix, or
+// have handle drop the warnings whose Var starts with another FlagSet's
+// scope.
+func WithWarnings(handle func(Warning)) Option {
+	return func(o *options) { o.warnings = handle }
+}
+
+// warn records w in the report and passes it to the handler, if there is one.
+func (o *options) warn(w Warning) {
+	o.report.warn(w)
+	if o.warnings != nil {
+		o.warnings(w)
+	}
+}
+
+// withLayer makes Override consider every flag which isn't in done,
+// whether or not it has been set. Load uses it to apply layers.
+func withLayer(done map[string]bool) Option {
+	return func(o *options) { o.layerDone = done }
+}
+
+// withFilter makes Override leave alone the flags for which keep returns false.
+func withFilter(keep func(*flag.Flag) bool) Option {
+	return func(o *options) { o.filter = keep }
+}
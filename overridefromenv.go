@@ -4,53 +4,492 @@
 // license that can be found in the LICENSE file.
 
 // Package overridefromenv is a library which sets unset flags from environment variables.
+//
+// Calls to Override on the same FlagSet are serialized, so several
+// goroutines may call it, even on the same FlagSet, while calls on
+// different FlagSets run independently. Callbacks given in options, like
+// warning handlers, error formatters and transforms, and the Source's
+// Lookup method, are called while the FlagSet is locked, so they must not
+// call Override or Load on the same FlagSet; trace handlers are called
+// after it is unlocked. Override sets flags through their Set methods
+// without any other synchronization, so reading a variable defined with the
+// flag package while Override may be setting it is a data race. Flags which
+// are read while configuration is reloaded should be defined with
+// AtomicFlag.
 package overridefromenv
 
 import (
 	"flag"
 	"fmt"
-	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
+// flagSetLocks holds a lock for each FlagSet being overridden, so calls to
+// Override on the same FlagSet are serialized. A lock is removed when no
+// call holds it or is waiting for it, so FlagSets aren't kept alive.
+var (
+	flagSetLocksMu sync.Mutex
+	flagSetLocks   = make(map[*flag.FlagSet]*flagSetLock)
+)
+
+// flagSetLock is the lock for a FlagSet, and the number of calls using it.
+type flagSetLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockFlagSet locks fs for a call to Override, and returns the function
+// which unlocks it.
+func lockFlagSet(fs *flag.FlagSet) func() {
+	flagSetLocksMu.Lock()
+	l := flagSetLocks[fs]
+	if l == nil {
+		l = &flagSetLock{}
+		flagSetLocks[fs] = l
+	}
+	l.refs++
+	flagSetLocksMu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		flagSetLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(flagSetLocks, fs)
+		}
+		flagSetLocksMu.Unlock()
+	}
+}
+
 // Override sets unset flags using environment variables.
 // It finds unset flags in fs, then sets those flags using the value of the
 // environment variable with the key strings.ToUpper(prefix+flag.Name).
-func Override(fs *flag.FlagSet, prefix string) error {
+// Flags are processed in lexicographical order.
+//
+// Flags defined with flag.Func or flag.BoolFunc are given to fs.Set rather
+// than to their own Set methods, so they are recorded as set, and set flags
+// of those kinds are always left alone, even by WithEnvPrecedence or a Load
+// layer above Args: their functions are called at most once, whether by
+// parsing the command line or by any number of calls to Override.
+// Options can change where values are looked up and record what was done.
+func Override(fs *flag.FlagSet, prefix string, opts ...Option) error {
+
+	// The traces are flushed after fs is unlocked.
+	o := newOptions(opts)
+	defer o.flushTraces()
+	defer lockFlagSet(fs)()
+	if err := o.checkParsed(fs); err != nil {
+		return err
+	}
+
+	// Find the unset flags with corresponding environment variables.
+	overrides, err := o.find(fs, prefix)
+	if err != nil {
+		return err
+	}
+
+	// Transform the values, decrypting them for example.
+	for i := range overrides {
+		if err := o.transform(&overrides[i]); err != nil {
+			return err
+		}
+	}
 
-	// A map of pointers to unset flags.
-	listOfUnsetFlags := make(map[*flag.Flag]bool)
+	// References to other flags need to be resolved after those flags are set.
+	if o.interpolate {
+		overrides, err = orderByReferences(fs, overrides)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Set each flag to the value we found.
+	// If there's a problem setting the flag value,
+	// there's a serious problem we can't recover from.
+	for _, p := range overrides {
+		value := p.value
+		if o.interpolate {
+			value, err = interpolate(fs, value)
+			if err != nil {
+				return p.error(err)
+			}
+		}
+		previous := p.flag.Value.String()
+		err = o.set(fs, p.flag, value)
+		if err != nil {
+			p.trace.finish("failed, " + err.Error())
+			return p.error(err)
+		}
+		p.trace.finish("set from " + p.name)
+		if o.defValues {
+			updateDefValue(p.flag, p.name, p.isSecret())
+		}
+		record := Provenance{
+			Flag:    p.flag.Name,
+			Var:     p.name,
+			Value:   p.redact(value),
+			Secret:  p.isSecret(),
+			Source:  keySourceName(o.source, p.name),
+			Time:    time.Now(),
+			BaseDir: p.baseDir,
+		}
+		if p.replace {
+			record.Replaced, record.Previous = true, p.redact(previous)
+		}
+		o.report.add(record)
+		if err := o.writeAudit(p, record, previous); err != nil {
+			return err
+		}
+	}
+
+	// Variables with the prefix which don't belong to any flag are probably
+	// mistakes. Without a prefix, that would be every other variable.
+	if prefix != "" {
+		for _, name := range o.unused(fs, prefix) {
+			o.warn(Warning{Var: name, Message: "doesn't correspond to any flag"})
+		}
+	}
+
+	// Layers are checked once they have all been applied.
+	if o.layerDone != nil {
+		return nil
+	}
+	return o.checkRequired(fs, prefix, overrides)
+}
+
+// checkRequired returns an error for the first required flag in fs, by
+// name, which was neither set nor overridden.
+func (o *options) checkRequired(fs *flag.FlagSet, prefix string, overrides []pending) error {
+	overridden := make(map[string]bool)
+	for _, p := range overrides {
+		overridden[p.flag.Name] = true
+	}
+	set := setFlags(fs)
+	for _, f := range requiredFlags(fs) {
+		if !set[f.Name] && !overridden[f.Name] {
+			return fmt.Errorf("flag %v must be set on the command line or with environment variable %v: %w",
+				f.Name, o.varNames(fs, prefix, f)[0], ErrRequired)
+		}
+	}
+	return nil
+}
+
+// set gives value to f, splitting it into several values first if f
+// has a separator.
+func (o *options) set(fs *flag.FlagSet, f *flag.Flag, value string) error {
+	values := []string{value}
+	if sep, ok := o.separators[f.Name]; ok {
+		values = strings.Split(value, sep)
+	}
+	for _, v := range values {
+		var err error
+		if isFuncFlag(f) {
+			err = fs.Set(f.Name, v)
+		} else {
+			err = f.Value.Set(v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find returns the flags in fs which Override may set and which have
+// values in the source, sorted by name. Those are the unset flags, along
+// with any set flags which the environment beats. When applying a layer for
+// Load, they are instead all the flags which the layers above haven't set.
+func (o *options) find(fs *flag.FlagSet, prefix string) ([]pending, error) {
 
 	// Visit calls a function on "only those flags that have been set."
 	// VisitAll calls a function on "all flags, even those not set."
-	// No way to ask for "only unset flags". So, we add all, then
-	// delete the set flags.
-
-	// First, visit all the flags, and add them to our map.
-	fs.VisitAll(func(f *flag.Flag) { listOfUnsetFlags[f] = true })
-
-	// Then delete the set flags.
-	fs.Visit(func(f *flag.Flag) { delete(listOfUnsetFlags, f) })
-
-	// Loop through our list of unset flags.
-	// We don't care about the values in our map, only the keys.
-	for f := range listOfUnsetFlags {
-		// Build the corresponding environment variable name for each flag.
-		envVarName := fmt.Sprintf("%v%v", strings.ToUpper(prefix), strings.ToUpper(f.Name))
-
-		// Look for the environment variable name.
-		// If found, set the flag to that value.
-		// If there's a problem setting the flag value,
-		// there's a serious problem we can't recover from.
-		envVarValue, found := os.LookupEnv(envVarName)
+	// No way to ask for "only unset flags". So, we collect the names of
+	// the set flags, which are usually few, then visit all the flags once,
+	// looking each one up. VisitAll visits flags in lexicographical order,
+	// which keeps errors and reports the same from run to run.
+	set := setFlags(fs)
+	o.snapshotEnviron(fs, prefix)
+	if err := o.checkReserved(fs, prefix); err != nil {
+		return nil, err
+	}
+	o.prefetch(fs, prefix, set)
+
+	var overrides []pending
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		var p pending
+		var found bool
+		p, found, err = o.resolve(fs, prefix, f, set[f.Name], o.startTrace(f))
 		if found {
-			err := f.Value.Set(envVarValue)
-			if err != nil {
-				return fmt.Errorf("unable to set flag %v from environment variable %v, "+
-					"which has a value of \"%v\": %w",
-					f.Name, envVarName, envVarValue, err)
-			}
+			overrides = append(overrides, p)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return o.addComposites(fs, prefix, set, overrides)
+}
+
+// resolve finds the variable Override would set f from, if it may set f,
+// recording what it does in t, which may be nil.
+func (o *options) resolve(fs *flag.FlagSet, prefix string, f *flag.Flag, set bool, t *Trace) (pending, bool, error) {
+	if reason := o.skipReason(f, set); reason != "" {
+		t.finish("skipped, " + reason)
+		return pending{}, false, nil
+	}
+	envVarName, envVarValue, found, err := o.lookup(fs, prefix, f, t)
+	if err != nil {
+		t.finish("failed, " + err.Error())
+		return pending{}, false, err
+	}
+	if !found {
+		t.finish("not found")
+		return pending{}, false, nil
+	}
+	t.finish("found in " + envVarName)
+	return pending{flag: f, name: envVarName, value: envVarValue, secret: keySecret(o.source, envVarName),
+		replace: set, format: o.errorFormatter, trace: t}, true, nil
+}
+
+// skipReason returns why Override leaves f alone, or the empty string if
+// Override may set f.
+func (o *options) skipReason(f *flag.Flag, set bool) string {
+	switch {
+	case o.skipFuncFlags && isFuncFlag(f):
+		return "defined with flag.Func or flag.BoolFunc"
+	case o.filter != nil && !o.filter(f):
+		return "excluded by a filter"
+	case !o.matches(f):
+		return "excluded by a pattern"
+	case set && isFuncFlag(f):
+		return "already set, and defined with flag.Func or flag.BoolFunc"
+	case o.layerDone != nil:
+		if o.layerDone[f.Name] {
+			return "set by a higher layer"
+		}
+		return ""
+	case set && !o.envFirst[f.Name]:
+		return "already set"
+	}
+	return ""
+}
+
+// setFlags returns the names of the flags in fs which have been set.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// pending is a flag which is going to be set from a variable.
+type pending struct {
+	flag    *flag.Flag
+	name    string // The name of the variable.
+	value   string // The value of the variable.
+	replace bool   // Whether the flag was already set.
+	secret  bool   // Whether the value is a secret, because it was encrypted.
+
+	// format builds errors setting the flag, if WithErrorFormatter was given.
+	format ErrorFormatter
+	// trace records how the flag was resolved, if WithTrace was given.
+	trace *Trace
+	// baseDir is the directory a relative path was resolved against.
+	baseDir string
+}
+
+// error describes a problem setting p's flag.
+// Secret values are redacted, unless the error is built by a formatter.
+func (p pending) error(err error) error {
+	if p.format != nil {
+		return p.format(p.flag.Name, p.name, p.value, err)
+	}
+	return fmt.Errorf("unable to set flag %v from environment variable %v, "+
+		"which has a value of \"%v\": %w",
+		p.flag.Name, p.name, p.redact(p.value), err)
+}
+
+// isSecret reports whether p's value is a secret.
+func (p pending) isSecret() bool {
+	return p.secret || isSecret(p.flag)
+}
+
+// redact returns value, unless p's value is a secret.
+func (p pending) redact(value string) string {
+	if p.isSecret() {
+		return redacted
+	}
+	return value
+}
+
+// A transform rewrites the value found for a flag before the flag is set.
+type transform func(p *pending) error
+
+// transform applies each of the transforms to p in turn, those which
+// decrypt values first, so the others see the plaintext whatever order
+// their options were given in. It then checks the result against the
+// limits on values, and resolves the value if it's a path.
+func (o *options) transform(p *pending) error {
+	for _, t := range append(append([]transform{}, o.decrypts...), o.transforms...) {
+		if err := t(p); err != nil {
+			p.trace.finish("failed, " + err.Error())
+			return p.error(err)
 		}
 	}
+	if err := o.validate(p); err != nil {
+		p.trace.finish("failed, " + err.Error())
+		return p.error(err)
+	}
+	if err := o.resolvePath(p); err != nil {
+		p.trace.finish("failed, " + err.Error())
+		return p.error(err)
+	}
 	return nil
 }
+
+// updateDefValue makes f's current value its default, unless it is a
+// secret, and notes in its usage message that it came from the variable
+// called name.
+func updateDefValue(f *flag.Flag, name string, secret bool) {
+	if !secret {
+		f.DefValue = f.Value.String()
+	}
+	note := fmt.Sprintf("(from environment variable %v)", name)
+	if !strings.HasSuffix(f.Usage, note) {
+		f.Usage = strings.TrimSpace(f.Usage + " " + note)
+	}
+}
+
+// VarName returns the name of the environment variable Override uses for
+// the flag called name, when no options change it.
+func VarName(prefix, name string) string {
+	return fmt.Sprintf("%v%v", strings.ToUpper(prefix), strings.ToUpper(name))
+}
+
+// CandidateNames returns every variable name Override would consider for
+// each flag in fs, in the order they're tried, keyed by flag name. The names
+// reflect the options, like scopes and explicit names given with Bind, so
+// the full contract between a program and its environment can be checked
+// by documentation generators, tests and deployment linters. Names which
+// InvalidNameSkip would skip are left out, as are system variables unless
+// WithSystemVars is given, and flags without any names are given an empty
+// list.
+func CandidateNames(fs *flag.FlagSet, prefix string, opts ...Option) map[string][]string {
+	o := newOptions(opts)
+	candidates := make(map[string][]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		names := []string{}
+		for _, name := range o.varNames(fs, prefix, f) {
+			checked, ok, err := o.checkName(f.Name, name)
+			switch {
+			case err != nil:
+				names = append(names, name)
+			case ok && !o.isSystemVar(f, checked):
+				names = append(names, checked)
+			}
+		}
+		candidates[f.Name] = names
+	})
+	return candidates
+}
+
+// varNames derives the variable names for f, in the order they should be
+// tried. A flag bound with an explicit variable name, or annotated with one,
+// only has that name. The names for the flag's old names, given with
+// WithRenamed, are tried after its own.
+func (o *options) varNames(fs *flag.FlagSet, prefix string, f *flag.Flag) []string {
+	if b, ok := binding(f); ok && b.Env != "" {
+		return []string{b.Env}
+	}
+	if name, ok := annotatedName(f); ok && o.annotations {
+		return []string{name}
+	}
+	names := o.scopedNames(fs, prefix, f.Name)
+	for _, old := range o.renamed[f.Name] {
+		names = append(names, o.scopedNames(fs, prefix, old)...)
+	}
+	return names
+}
+
+// scopedNames returns the variable names for the flag called name in each
+// of the scopes, in the order they're tried, ending with the prefix alone.
+func (o *options) scopedNames(fs *flag.FlagSet, prefix, name string) []string {
+	name = o.groupName(name)
+	scopes := o.scopes[:len(o.scopes):len(o.scopes)]
+	if profile := o.profile(prefix); profile != "" {
+		scopes = append(scopes, profile)
+	}
+	if o.hostScope != "" {
+		scopes = append(scopes, o.hostScope)
+	}
+	if ns := namespace(fs); o.namespace && ns != "" {
+		prefix = prefix + ns + "_"
+	}
+	names := make([]string, 0, len(scopes)+1)
+	for i := len(scopes) - 1; i >= 0; i-- {
+		names = append(names, VarName(prefix+scopes[i], name))
+	}
+	return append(names, VarName(prefix, name))
+}
+
+// namespace returns the name of fs as WithNamespace inserts it. The name
+// of flag.CommandLine is the program's path, so only the last element of a
+// path is used, and the runes which don't belong in a variable name are
+// replaced with underscores, as with WithHostScope.
+func namespace(fs *flag.FlagSet) string {
+	name := fs.Name()
+	if name == "" {
+		return ""
+	}
+	name = strings.TrimSuffix(filepath.Base(name), ".exe")
+	return strings.Map(hostRune, strings.ToUpper(name))
+}
+
+// lookup tries each of the variable names for f in turn, returning the
+// first one found in the source along with its value.
+// Each attempt is recorded in t, which may be nil.
+func (o *options) lookup(fs *flag.FlagSet, prefix string, f *flag.Flag, t *Trace) (string, string, bool, error) {
+	for _, candidate := range o.varNames(fs, prefix, f) {
+		// Deal with names which can't be environment variables.
+		name, ok, err := o.checkName(f.Name, candidate)
+		if err != nil {
+			return "", "", false, err
+		}
+		if !ok {
+			o.warn(Warning{Flag: f.Name, Var: candidate, Message: "skipped, as it isn't a valid name"})
+			t.try(candidate, false, "skipped, as it isn't a valid name")
+			continue
+		}
+		if o.isSystemVar(f, name) {
+			o.warn(Warning{Flag: f.Name, Var: name, Message: "skipped, as it is a system variable"})
+			t.try(name, false, "skipped, as it is a system variable")
+			continue
+		}
+		note := ""
+		if name != candidate {
+			note = fmt.Sprintf("used in place of the invalid name %q", candidate)
+			o.warn(Warning{Flag: f.Name, Var: name, Message: note})
+		}
+		value, found := o.source.Lookup(name)
+		if found && value == "" && o.boolPresence && isBoolFlag(f) {
+			value = "true"
+		}
+		if found && value == "" && o.emptyAsUnset {
+			o.warn(Warning{Flag: f.Name, Var: name, Message: "ignored, as it is empty"})
+			t.try(name, true, "ignored, as it is empty")
+			continue
+		}
+		t.try(name, found, note)
+		if found {
+			o.warnRenamed(fs, prefix, f, name)
+			return name, value, true, nil
+		}
+		o.report.miss(name)
+	}
+	return "", "", false, nil
+}
@@ -6,8 +6,12 @@
 package overridefromenv
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -134,3 +138,467 @@ func TestOverrideUnsetFlags(t *testing.T) {
 		t.Error("uint64 flag was not overwritten.")
 	}
 }
+
+func TestOverrideWithSourceAndReport(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	s := fs.String("stringtest", "default", "")
+	fs.Int("inttest", 1, "")
+
+	source := SourceFunc(func(key string) (string, bool) {
+		if key == "TEST_STRINGTEST" {
+			return "fromsource", true
+		}
+		return "", false
+	})
+
+	var r Report
+	err := Override(fs, "TEST_", WithSource(source), WithReport(&r))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *s != "fromsource" {
+		t.Error("string flag was not overwritten from the source.")
+	}
+	want := Provenance{Flag: "stringtest", Var: "TEST_STRINGTEST", Value: "fromsource", Source: "overridefromenv.SourceFunc"}
+	if len(r.Overridden) != 1 || r.Overridden[0].Time.IsZero() {
+		t.Fatalf("Report was %+v, want one entry with a time.", r.Overridden)
+	}
+	if r.Overridden[0].Time = (time.Time{}); r.Overridden[0] != want {
+		t.Errorf("Report was %+v, want %+v.", r.Overridden[0], want)
+	}
+}
+
+func TestOverrideInvalidNamePolicy(t *testing.T) {
+
+	source := SourceFunc(func(key string) (string, bool) {
+		switch key {
+		case "TEST_MAX SIZE":
+			return "raw", true
+		case "TEST_MAX_SIZE":
+			return "sanitized", true
+		}
+		return "", false
+	})
+
+	tests := []struct {
+		policy InvalidNamePolicy
+		want   string
+		err    bool
+	}{
+		{InvalidNameAllow, "raw", false},
+		{InvalidNameError, "default", true},
+		{InvalidNameSkip, "default", false},
+		{InvalidNameSanitize, "sanitized", false},
+	}
+
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ExitOnError)
+		s := fs.String("max size", "default", "")
+		err := Override(fs, "TEST_", WithSource(source), WithInvalidNamePolicy(test.policy))
+		if (err != nil) != test.err {
+			t.Errorf("policy %v: unexpected error result %v.", test.policy, err)
+		}
+		if test.err && !errors.Is(err, ErrInvalidName) {
+			t.Errorf("policy %v: error %v doesn't wrap ErrInvalidName.", test.policy, err)
+		}
+		if *s != test.want {
+			t.Errorf("policy %v: flag was %q, want %q.", test.policy, *s, test.want)
+		}
+	}
+}
+
+func TestOverrideOrder(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	for _, name := range []string{"delta", "alpha", "charlie", "bravo"} {
+		fs.Int(name, 0, "")
+	}
+
+	bad := SourceFunc(func(key string) (string, bool) { return "bad", true })
+	for i := 0; i < 10; i++ {
+		err := Override(fs, "TEST_", WithSource(bad))
+		if err == nil || !strings.Contains(err.Error(), "flag alpha ") {
+			t.Fatalf("Override error was %v, want one about flag alpha.", err)
+		}
+	}
+
+	var r Report
+	source := SourceFunc(func(key string) (string, bool) { return "1", true })
+	for i := 0; i < 10; i++ {
+		if err := Override(fs, "TEST_", WithSource(source), WithReport(&r)); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+		for j, want := range []string{"alpha", "bravo", "charlie", "delta"} {
+			if r.Overridden[j].Flag != want {
+				t.Fatalf("flag %d overridden was %v, want %v.", j, r.Overridden[j].Flag, want)
+			}
+		}
+	}
+}
+
+func TestOverrideWithNamespace(t *testing.T) {
+
+	source := SourceFunc(func(key string) (string, bool) {
+		switch key {
+		case "APP_PORT":
+			return "1", true
+		case "APP_SERVE_PORT":
+			return "2", true
+		case "APP_ADMIN_PORT":
+			return "3", true
+		}
+		return "", false
+	})
+
+	serve := flag.NewFlagSet("serve", flag.ExitOnError)
+	servePort := serve.Int("port", 0, "")
+	admin := flag.NewFlagSet("admin", flag.ExitOnError)
+	adminPort := admin.Int("port", 0, "")
+
+	for _, fs := range []*flag.FlagSet{serve, admin} {
+		if err := Override(fs, "APP_", WithSource(source), WithNamespace()); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+	}
+	if *servePort != 2 || *adminPort != 3 {
+		t.Errorf("namespaced flags were %v and %v, want 2 and 3.", *servePort, *adminPort)
+	}
+
+	// A FlagSet named after a program's path, like flag.CommandLine, is
+	// namespaced by the program's name.
+	for name, want := range map[string]string{"./bin/serve": "APP_SERVE_PORT", "serve-api": "APP_SERVE_API_PORT", "": "APP_PORT"} {
+		fs := flag.NewFlagSet(name, flag.ExitOnError)
+		fs.Int("port", 0, "")
+		if got := CandidateNames(fs, "APP_", WithNamespace())["port"]; len(got) != 1 || got[0] != want {
+			t.Errorf("the flag port in the FlagSet %q was read from %q, want %v.", name, got, want)
+		}
+	}
+}
+
+func TestOverrideWithScopes(t *testing.T) {
+
+	source := SourceFunc(func(key string) (string, bool) {
+		switch key {
+		case "APP_LEVEL":
+			return "shared", true
+		case "APP_PORT":
+			return "1", true
+		case "APP_SERVE_PORT":
+			return "2", true
+		case "APP_SERVE_HTTP_PORT":
+			return "3", true
+		}
+		return "", false
+	})
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	level := fs.String("level", "default", "")
+	port := fs.Int("port", 0, "")
+	if err := Override(fs, "APP_", WithSource(source), WithScopes("SERVE_")); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *level != "shared" || *port != 2 {
+		t.Errorf("flags were %q and %v, want \"shared\" and 2.", *level, *port)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ExitOnError)
+	port = fs.Int("port", 0, "")
+	if err := Override(fs, "APP_", WithSource(source), WithScopes("SERVE_", "SERVE_HTTP_")); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 3 {
+		t.Errorf("flag was %v, want the most specific scope's value, 3.", *port)
+	}
+}
+
+func TestOverrideWithDefValues(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Int("powerlevel", 0, "power level")
+	fs.Duration("timeout", time.Second, "how long to wait")
+	var token string
+	Bind(fs, &token, "token", "", "the API token", BindOpts{Secret: true})
+
+	source := SourceFunc(func(key string) (string, bool) {
+		switch key {
+		case "APP_POWERLEVEL":
+			return "9000", true
+		case "APP_TOKEN":
+			return "hunter2", true
+		}
+		return "", false
+	})
+	for i := 0; i < 2; i++ {
+		if err := Override(fs, "APP_", WithSource(source), WithDefValues()); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+	}
+
+	f := fs.Lookup("powerlevel")
+	if f.DefValue != "9000" {
+		t.Errorf("DefValue was %q, want \"9000\".", f.DefValue)
+	}
+	if f.Usage != "power level (from environment variable APP_POWERLEVEL)" {
+		t.Errorf("Usage was %q.", f.Usage)
+	}
+	if f := fs.Lookup("timeout"); f.DefValue != "1s" || f.Usage != "how long to wait" {
+		t.Errorf("a flag which wasn't overridden was changed to %q, %q.", f.DefValue, f.Usage)
+	}
+	if f := fs.Lookup("token"); f.DefValue != "" || f.Usage != "the API token (from environment variable APP_TOKEN)" {
+		t.Errorf("a secret flag was changed to %q, %q.", f.DefValue, f.Usage)
+	}
+}
+
+func TestOverrideWithEmptyAsUnset(t *testing.T) {
+
+	source := MapSource{"APP_PORT": "", "APP_NAME": "", "APP_SERVE_NAME": ""}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Int("port", 80, "")
+	if err := Override(fs, "APP_", WithSource(source)); err == nil {
+		t.Error("Overriding an int flag with an empty value didn't cause an error.")
+	}
+
+	fs = flag.NewFlagSet("test", flag.ExitOnError)
+	port := fs.Int("port", 80, "")
+	name := fs.String("name", "default", "")
+	err := Override(fs, "APP_", WithSource(source), WithEmptyAsUnset(), WithScopes("SERVE_"))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 80 || *name != "default" {
+		t.Errorf("flags were %v and %q, want the defaults.", *port, *name)
+	}
+}
+
+// listValue is a flag.Value which accumulates values.
+type listValue []string
+
+func (l *listValue) String() string     { return strings.Join(*l, ",") }
+func (l *listValue) Set(s string) error { *l = append(*l, s); return nil }
+
+func TestOverrideWithSeparator(t *testing.T) {
+
+	source := MapSource{"APP_HOST": "a,b,c", "APP_NAME": "x,y"}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	var hosts listValue
+	fs.Var(&hosts, "host", "")
+	name := fs.String("name", "", "")
+	if err := Override(fs, "APP_", WithSource(source), WithSeparator(",", "host")); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if len(hosts) != 3 || hosts[0] != "a" || hosts[2] != "c" {
+		t.Errorf("list flag was %q, want [a b c].", hosts)
+	}
+	if *name != "x,y" {
+		t.Errorf("flag without a separator was %q, want \"x,y\".", *name)
+	}
+}
+
+func TestOverrideWithEnvPrecedence(t *testing.T) {
+
+	source := MapSource{"PORT": "8080", "NAME": "fromenv"}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	port := fs.Int("port", 80, "")
+	name := fs.String("name", "default", "")
+	fs.Parse([]string{"-port", "3000", "-name", "fromargs"})
+
+	var r Report
+	if err := Override(fs, "", WithSource(source), WithEnvPrecedence("port"), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 {
+		t.Errorf("env-authoritative flag was %v, want 8080.", *port)
+	}
+	if *name != "fromargs" {
+		t.Errorf("set flag was overridden to %q.", *name)
+	}
+	want := Provenance{Flag: "port", Var: "PORT", Value: "8080", Replaced: true, Previous: "3000", Source: "overridefromenv.MapSource"}
+	if len(r.Overridden) == 1 {
+		r.Overridden[0].Time = time.Time{}
+	}
+	if len(r.Overridden) != 1 || r.Overridden[0] != want {
+		t.Errorf("Report was %+v, want one entry %+v.", r.Overridden, want)
+	}
+}
+
+func BenchmarkOverrideLargeFlagSet(b *testing.B) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	for i := 0; i < 5000; i++ {
+		fs.Int(fmt.Sprintf("generated-%d", i), 0, "")
+	}
+	fs.Parse([]string{"-generated-1", "1", "-generated-2", "2"})
+	source := MapSource{"APP_GENERATED-10": "10"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Override(fs, "APP_", WithSource(source)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestOverrideWithErrorFormatter(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+
+	format := func(flagName, envVar, value string, err error) error {
+		return fmt.Errorf("%v=%q n'est pas valide pour -%v: %w", envVar, value, flagName, err)
+	}
+	source := MapSource{"APP_PORT": "http"}
+	err := Override(fs, "APP_", WithSource(source), WithErrorFormatter(format))
+	if err == nil {
+		t.Fatal("Override didn't return an error.")
+	}
+	if !strings.HasPrefix(err.Error(), `APP_PORT="http" n'est pas valide pour -port: `) {
+		t.Errorf("Override returned %q, which wasn't built by the formatter.", err)
+	}
+}
+
+func TestOverrideWithWarnings(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	fs.String("name", "", "")
+	fs.String("bad name", "", "")
+
+	source := MapSource{"APP_PORT": "", "APP_NAME": "x", "APP_PROT": "8080"}
+	var handled []Warning
+	var r Report
+	err := Override(fs, "APP_", WithSource(source), WithReport(&r), WithEmptyAsUnset(),
+		WithInvalidNamePolicy(InvalidNameSkip), WithWarnings(func(w Warning) { handled = append(handled, w) }))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+
+	want := []Warning{
+		{Flag: "bad name", Var: "APP_BAD NAME", Message: "skipped, as it isn't a valid name"},
+		{Flag: "port", Var: "APP_PORT", Message: "ignored, as it is empty"},
+		{Var: "APP_PROT", Message: "doesn't correspond to any flag"},
+	}
+	if !reflect.DeepEqual(r.Warnings, want) {
+		t.Errorf("Override reported warnings %v, want %v.", r.Warnings, want)
+	}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("Override handled warnings %v, want %v.", handled, want)
+	}
+	if len(r.Overridden) != 1 {
+		t.Errorf("Override reported %v overrides, want 1.", len(r.Overridden))
+	}
+}
+
+func TestOverrideReportMissing(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("host", "", "")
+	fs.Int("port", 80, "")
+	fs.String("name", "", "")
+	fs.Parse([]string{"-host", "example.com"})
+
+	var r Report
+	source := MapSource{"APP_PORT": "8080"}
+	if err := Override(fs, "APP_", WithSource(source), WithScopes("SERVE_"), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	want := []string{"APP_SERVE_NAME", "APP_NAME", "APP_SERVE_PORT"}
+	if !reflect.DeepEqual(r.Missing, want) {
+		t.Errorf("Override reported %v missing, want %v.", r.Missing, want)
+	}
+}
+
+func TestOverrideWithBoolPresence(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "")
+	color := fs.Bool("color", true, "")
+	name := fs.String("name", "default", "")
+
+	source := MapSource{"APP_VERBOSE": "", "APP_COLOR": "false", "APP_NAME": ""}
+	if err := Override(fs, "APP_", WithSource(source), WithBoolPresence(), WithEmptyAsUnset()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if !*verbose || *color || *name != "default" {
+		t.Errorf("flags were verbose=%v, color=%v and name=%q.", *verbose, *color, *name)
+	}
+}
+
+func TestOverrideIntegerLiterals(t *testing.T) {
+
+	// The flag package parses integers with base 0, so Go's literal
+	// syntax is accepted without any help from Override.
+	tests := map[string]int64{"0x1F": 31, "0b101": 5, "0o17": 15, "017": 15, "1_000_000": 1000000, "-0x10": -16}
+	for value, want := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		i := fs.Int64("count", 0, "")
+		u := fs.Uint("size", 0, "")
+		source := MapSource{"APP_COUNT": value}
+		if want > 0 {
+			source["APP_SIZE"] = value
+		}
+		if err := Override(fs, "APP_", WithSource(source)); err != nil {
+			t.Fatalf("Override returned an error for %q: %v", value, err)
+		}
+		if *i != want || want > 0 && *u != uint(want) {
+			t.Errorf("%q was parsed as %v and %v, want %v.", value, *i, *u, want)
+		}
+	}
+}
+
+func TestCandidateNames(t *testing.T) {
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	fs.String("bad name", "", "")
+	var token string
+	Bind(fs, &token, "token", "", "", BindOpts{Env: "TOKEN"})
+
+	got := CandidateNames(fs, "APP_", WithScopes("A_", "B_"), WithInvalidNamePolicy(InvalidNameSkip))
+	want := map[string][]string{
+		"port":     {"APP_B_PORT", "APP_A_PORT", "APP_PORT"},
+		"bad name": {},
+		"token":    {"TOKEN"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CandidateNames returned %v, want %v.", got, want)
+	}
+}
+
+func TestOverrideFromCallbacks(t *testing.T) {
+
+	audit := flag.NewFlagSet("audit", flag.ContinueOnError)
+	level := audit.String("level", "info", "")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	env := MapSource{"APP_PORT": "8080", "APP_TYPO": "x", "AUDIT_LEVEL": "debug"}
+
+	// A warning handler may override another FlagSet, and a trace handler
+	// may override the same one, as it is called once fs is unlocked.
+	done := make(chan error)
+	go func() {
+		done <- Override(fs, "APP_", WithSource(env),
+			WithWarnings(func(Warning) { Override(audit, "AUDIT_", WithSource(env)) }),
+			WithTrace(func(Trace) { Override(fs, "APP_", WithSource(env)) }))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Override returned an error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Override called from a callback deadlocked.")
+	}
+	if *level != "debug" {
+		t.Errorf("level was %q, want debug.", *level)
+	}
+
+	flagSetLocksMu.Lock()
+	defer flagSetLocksMu.Unlock()
+	if len(flagSetLocks) != 0 {
+		t.Errorf("%v FlagSets were still locked.", len(flagSetLocks))
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package overridefromenvtest provides helpers for testing programs which
+// use overridefromenv, without modifying the process environment.
+package overridefromenvtest
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cu-library/overridefromenv"
+)
+
+// Env is a fake environment.
+// It implements overridefromenv.Lister, so it can be passed to
+// overridefromenv.WithSource in place of the process environment.
+type Env map[string]string
+
+// Lookup retrieves the value of the variable called key.
+func (e Env) Lookup(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+// Keys returns the names of the variables in the fake environment.
+func (e Env) Keys() []string {
+	return sortedKeys(e)
+}
+
+// Overridden runs overridefromenv.Override on fs using env in place of the
+// process environment, and returns the names of the flags which were set.
+func Overridden(fs *flag.FlagSet, prefix string, env Env, opts ...overridefromenv.Option) ([]string, error) {
+
+	var r overridefromenv.Report
+	opts = append(opts, overridefromenv.WithSource(env), overridefromenv.WithReport(&r))
+	err := overridefromenv.Override(fs, prefix, opts...)
+
+	names := []string{}
+	for _, p := range r.Overridden {
+		names = append(names, p.Flag)
+	}
+	return names, err
+}
+
+// AssertOverridden fails t unless overriding fs from env sets exactly the
+// flags named in want. The order of want does not matter.
+func AssertOverridden(t testing.TB, fs *flag.FlagSet, prefix string, env Env, want []string) {
+	t.Helper()
+
+	got, err := Overridden(fs, prefix, env)
+	if err != nil {
+		t.Errorf("Override returned an error: %v", err)
+		return
+	}
+
+	sort.Strings(got)
+	sorted := append([]string{}, want...)
+	sort.Strings(sorted)
+	if !reflect.DeepEqual(got, sorted) {
+		t.Errorf("overridden flags were %q, want %q", got, sorted)
+	}
+}
+
+// Mapping returns the environment variable name Override tries first for
+// each flag in fs, keyed by flag name. It accepts the same options as
+// Override; see overridefromenv.CandidateNames for all the names tried.
+func Mapping(fs *flag.FlagSet, prefix string, opts ...overridefromenv.Option) map[string]string {
+	m := make(map[string]string)
+	for name, candidates := range overridefromenv.CandidateNames(fs, prefix, opts...) {
+		if len(candidates) > 0 {
+			m[name] = candidates[0]
+		}
+	}
+	return m
+}
+
+// AssertMapping fails t unless the flag to environment variable mapping of
+// fs is exactly want, reporting every difference.
+func AssertMapping(t testing.TB, fs *flag.FlagSet, prefix string, want map[string]string, opts ...overridefromenv.Option) {
+	t.Helper()
+
+	got := Mapping(fs, prefix, opts...)
+	for _, name := range sortedKeys(got, want) {
+		g, inGot := got[name]
+		w, inWant := want[name]
+		switch {
+		case !inWant:
+			t.Errorf("flag %v maps to %v, but is not in the expected mapping", name, g)
+		case !inGot:
+			t.Errorf("flag %v is in the expected mapping, but is not defined", name)
+		case g != w:
+			t.Errorf("flag %v maps to %v, want %v", name, g, w)
+		}
+	}
+}
+
+// sortedKeys returns the union of the keys in the maps, sorted.
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UpdateGoldenVar is the environment variable which makes
+// AssertGoldenMapping write its golden files instead of checking them.
+const UpdateGoldenVar = "OVERRIDEFROMENVTEST_UPDATE"
+
+// GoldenMapping returns the complete mapping from the flags in fs to the
+// variables Override tries for them, one flag per line in order of name,
+// with the flag's name followed by its variables in the order they're tried.
+// It accepts the same options as Override.
+func GoldenMapping(fs *flag.FlagSet, prefix string, opts ...overridefromenv.Option) string {
+	var b strings.Builder
+	candidates := overridefromenv.CandidateNames(fs, prefix, opts...)
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(strings.Join(append([]string{name}, candidates[name]...), "\t") + "\n")
+	}
+	return b.String()
+}
+
+// AssertGoldenMapping fails t unless the mapping of fs, as returned by
+// GoldenMapping, is the same as the one in the golden file at path,
+// reporting the lines which differ, so that accidental renames of
+// variables are caught before they break deployments. When the
+// OVERRIDEFROMENVTEST_UPDATE environment variable is set to anything but
+// the empty string, the golden file is written instead, creating it if need
+// be, and the result can be reviewed and committed:
+//
+//	OVERRIDEFROMENVTEST_UPDATE=1 go test ./...
+func AssertGoldenMapping(t testing.TB, fs *flag.FlagSet, prefix, path string, opts ...overridefromenv.Option) {
+	t.Helper()
+
+	got := GoldenMapping(fs, prefix, opts...)
+	if os.Getenv(UpdateGoldenVar) != "" {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Errorf("unable to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("unable to read golden file, set %v=1 to create it: %v", UpdateGoldenVar, err)
+		return
+	}
+	if got == string(want) {
+		return
+	}
+	gotLines := lineSet(got)
+	wantLines := lineSet(string(want))
+	for _, line := range strings.SplitAfter(string(want), "\n") {
+		if line != "" && !gotLines[line] {
+			t.Errorf("mapping no longer has %q", strings.TrimSuffix(line, "\n"))
+		}
+	}
+	for _, line := range strings.SplitAfter(got, "\n") {
+		if line != "" && !wantLines[line] {
+			t.Errorf("mapping has unexpected %q", strings.TrimSuffix(line, "\n"))
+		}
+	}
+	t.Errorf("mapping differs from golden file %v, set %v=1 to update it", path, UpdateGoldenVar)
+}
+
+// lineSet returns the lines in s, with their line endings, as a set.
+func lineSet(s string) map[string]bool {
+	lines := make(map[string]bool)
+	for _, line := range strings.SplitAfter(s, "\n") {
+		lines[line] = true
+	}
+	return lines
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenvtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cu-library/overridefromenv"
+)
+
+// recorder is a testing.TB which records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestOverridden(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	s := fs.String("name", "default", "")
+	fs.Int("count", 1, "")
+	fs.Bool("verbose", false, "")
+	fs.Parse([]string{"-verbose"})
+
+	env := Env{"TEST_NAME": "fromenv", "TEST_VERBOSE": "false"}
+	got, err := Overridden(fs, "TEST_", env)
+	if err != nil {
+		t.Fatalf("Overridden returned an error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "name" {
+		t.Errorf("Overridden returned %q, want [\"name\"].", got)
+	}
+	if *s != "fromenv" {
+		t.Error("string flag was not overwritten from the fake environment.")
+	}
+}
+
+func TestAssertOverridden(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("name", "default", "")
+	fs.Int("count", 1, "")
+
+	AssertOverridden(t, fs, "TEST_", Env{"TEST_NAME": "a", "TEST_COUNT": "2"}, []string{"count", "name"})
+
+	r := &recorder{TB: t}
+	AssertOverridden(r, fs, "TEST_", Env{"TEST_COUNT": "2"}, []string{"name"})
+	if len(r.failures) != 1 {
+		t.Errorf("AssertOverridden reported %d failures, want 1.", len(r.failures))
+	}
+
+	r = &recorder{TB: t}
+	AssertOverridden(r, fs, "TEST_", Env{"TEST_COUNT": "two"}, []string{"count"})
+	if len(r.failures) != 1 {
+		t.Errorf("AssertOverridden didn't report the Override error.")
+	}
+}
+
+func TestAssertMapping(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("name", "default", "")
+	fs.Int("count", 1, "")
+	fs.Bool("verbose", false, "")
+
+	AssertMapping(t, fs, "test_", map[string]string{
+		"name":    "TEST_NAME",
+		"count":   "TEST_COUNT",
+		"verbose": "TEST_VERBOSE",
+	})
+
+	r := &recorder{TB: t}
+	AssertMapping(r, fs, "test_", map[string]string{
+		"name":    "TEST_NAME",
+		"count":   "TEST_NUMBER",
+		"missing": "TEST_MISSING",
+	})
+	if len(r.failures) != 3 {
+		t.Errorf("AssertMapping reported %d failures, want 3: %q", len(r.failures), r.failures)
+	}
+
+	// The mapping follows the options.
+	AssertMapping(t, fs, "test_", map[string]string{
+		"name":    "TEST_SERVE_NAME",
+		"count":   "TEST_SERVE_COUNT",
+		"verbose": "TEST_SERVE_VERBOSE",
+	}, overridefromenv.WithScopes("SERVE_"))
+}
+
+func TestAssertGoldenMapping(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("name", "default", "")
+	fs.Int("count", 1, "")
+
+	path := filepath.Join(t.TempDir(), "mapping.golden")
+	r := &recorder{TB: t}
+	AssertGoldenMapping(r, fs, "TEST_", path)
+	if len(r.failures) != 1 {
+		t.Errorf("AssertGoldenMapping reported %d failures without a golden file, want 1: %q", len(r.failures), r.failures)
+	}
+
+	t.Setenv(UpdateGoldenVar, "1")
+	AssertGoldenMapping(t, fs, "TEST_", path, overridefromenv.WithScopes("SERVE_"))
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read the golden file: %v", err)
+	}
+	if want := "count\tTEST_SERVE_COUNT\tTEST_COUNT\nname\tTEST_SERVE_NAME\tTEST_NAME\n"; string(golden) != want {
+		t.Errorf("golden file was %q, want %q.", golden, want)
+	}
+
+	t.Setenv(UpdateGoldenVar, "")
+	AssertGoldenMapping(t, fs, "TEST_", path, overridefromenv.WithScopes("SERVE_"))
+
+	// Renaming a flag is reported.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("name", "default", "")
+	fs.Int("number", 1, "")
+	r = &recorder{TB: t}
+	AssertGoldenMapping(r, fs, "TEST_", path, overridefromenv.WithScopes("SERVE_"))
+	if len(r.failures) != 3 {
+		t.Errorf("AssertGoldenMapping reported %d failures, want 3: %q", len(r.failures), r.failures)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"sync"
+)
+
+// WithParallelLookups makes Override look up the variables for all the
+// flags it may set before setting any, with up to workers lookups at a
+// time, so that a Source which makes a network call for each variable
+// costs about as long as its slowest call rather than the sum of them all.
+// The flags are then set in the usual order, so reports, traces and errors
+// don't depend on which lookup finished first. The Source must be safe for
+// concurrent use. Sources which fetch all their values up front, like
+// those in this package, gain nothing from it.
+func WithParallelLookups(workers int) Option {
+	return func(o *options) { o.workers = workers }
+}
+
+// prefetch replaces the source with one holding the results of looking up,
+// concurrently, every variable Override might read for the flags in fs.
+func (o *options) prefetch(fs *flag.FlagSet, prefix string, set map[string]bool) {
+	if o.workers < 1 {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if o.skipReason(f, set[f.Name]) != "" {
+			return
+		}
+		for _, name := range o.varNames(fs, prefix, f) {
+			if name, ok, _ := o.checkName(f.Name, name); ok {
+				add(name)
+			}
+		}
+	})
+	for _, c := range o.composites {
+		add(c.name(prefix))
+	}
+
+	results := make([]lookupResult, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < o.workers && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].value, results[i].found = o.source.Lookup(names[i])
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	p := prefetchSource{source: o.source, results: make(map[string]lookupResult, len(names))}
+	for i, name := range names {
+		p.results[name] = results[i]
+	}
+	if lister, ok := o.source.(Lister); ok {
+		o.source = prefetchLister{p, lister}
+		return
+	}
+	o.source = p
+}
+
+// lookupResult is the result of looking up a variable.
+type lookupResult struct {
+	value string
+	found bool
+}
+
+// prefetchSource serves the variables looked up by prefetch, and looks up
+// any others in the original source.
+type prefetchSource struct {
+	source  Source
+	results map[string]lookupResult
+}
+
+// Lookup retrieves the value of the variable called key.
+func (p prefetchSource) Lookup(key string) (string, bool) {
+	if r, ok := p.results[key]; ok {
+		return r.value, r.found
+	}
+	return p.source.Lookup(key)
+}
+
+// String describes the original source.
+func (p prefetchSource) String() string {
+	return sourceName(p.source)
+}
+
+// SourceName describes the source in the original source holding the
+// variable called key.
+func (p prefetchSource) SourceName(key string) string {
+	return keySourceName(p.source, key)
+}
+
+// SecretKey reports whether the original source says the variable called
+// key holds a secret.
+func (p prefetchSource) SecretKey(key string) bool {
+	return keySecret(p.source, key)
+}
+
+// prefetchLister is a prefetchSource whose original source is a Lister.
+type prefetchLister struct {
+	prefetchSource
+	lister Lister
+}
+
+// Keys returns the names of the variables in the original source.
+func (p prefetchLister) Keys() []string {
+	return p.lister.Keys()
+}
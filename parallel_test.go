@@ -0,0 +1,66 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithParallelLookups(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	values := make([]*int, 8)
+	for i := range values {
+		values[i] = fs.Int(fmt.Sprintf("flag%v", i), 0, "")
+	}
+	if err := fs.Parse([]string{"-flag7", "70"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	running, most, calls := 0, 0, 0
+	slow := SourceFunc(func(key string) (string, bool) {
+		mu.Lock()
+		running++
+		calls++
+		if running > most {
+			most = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		var i int
+		if _, err := fmt.Sscanf(key, "APP_FLAG%d", &i); err != nil {
+			return "", false
+		}
+		return fmt.Sprint(i * 10), true
+	})
+
+	var r Report
+	err := Override(fs, "APP_", WithSource(slow), WithReport(&r), WithParallelLookups(4))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	for i, v := range values {
+		if *v != i*10 {
+			t.Errorf("flag%v was %v, want %v.", i, *v, i*10)
+		}
+	}
+	if most < 2 || most > 4 || calls != 7 {
+		t.Errorf("%v lookups were made, at most %v at a time, want 7 and no more than 4.", calls, most)
+	}
+	for i, p := range r.Overridden {
+		if p.Flag != fmt.Sprintf("flag%v", i) || p.Source != "overridefromenv.SourceFunc" {
+			t.Errorf("override %v was %+v.", i, p)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// ErrNotParsed is wrapped by the error Override returns under
+// WithRequireParsed when the FlagSet hasn't been parsed.
+var ErrNotParsed = errors.New("flag set has not been parsed")
+
+// WithRequireParsed makes Override return an error wrapping ErrNotParsed
+// if fs.Parsed reports that the FlagSet hasn't been parsed yet. Before
+// Parse, every flag looks unset, so Override sets them all from the
+// environment, and the command line parsed afterwards quietly replaces
+// those values, which makes the mistake easy to miss. To have Override run
+// whenever the arguments are parsed instead, use an EnvFlagSet.
+// Load parses the arguments in an Args layer before it applies any layer,
+// so given to a layer, the option only refuses a Load without one.
+func WithRequireParsed() Option {
+	return func(o *options) { o.requireParsed = true }
+}
+
+// checkParsed returns an error if Override requires fs to have been parsed
+// and it hasn't been.
+func (o *options) checkParsed(fs *flag.FlagSet) error {
+	if !o.requireParsed || fs.Parsed() {
+		return nil
+	}
+	return fmt.Errorf("unable to override flag set %q: %w", fs.Name(), ErrNotParsed)
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestWithRequireParsed(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "")
+	env := MapSource{"APP_PORT": "7777"}
+
+	err := Override(fs, "APP_", WithSource(env), WithRequireParsed())
+	if !errors.Is(err, ErrNotParsed) {
+		t.Errorf("Override returned %v before Parse, want ErrNotParsed.", err)
+	}
+	if *port != 8080 {
+		t.Errorf("port was set to %v before Parse.", *port)
+	}
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Override(fs, "APP_", WithSource(env), WithRequireParsed()); err != nil {
+		t.Errorf("Override returned an error after Parse: %v", err)
+	}
+	if *port != 7777 {
+		t.Errorf("port was %v, want 7777.", *port)
+	}
+}
+
+func TestWithRequireParsedLoad(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "")
+	env := MapSource{"APP_PORT": "7777"}
+	err := Load(fs, Layers{Defaults, Env("APP_", WithSource(env), WithRequireParsed())})
+	if !errors.Is(err, ErrNotParsed) {
+		t.Errorf("Load returned %v without an Args layer, want ErrNotParsed.", err)
+	}
+	if *port != 8080 {
+		t.Errorf("port was set to %v without an Args layer.", *port)
+	}
+
+	err = Load(fs, Layers{Defaults, Env("APP_", WithSource(env), WithRequireParsed()), Args(nil)})
+	if err != nil {
+		t.Errorf("Load returned an error: %v", err)
+	}
+	if *port != 7777 {
+		t.Errorf("port was %v, want 7777.", *port)
+	}
+}
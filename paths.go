@@ -0,0 +1,141 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// WithPaths marks the values of the named flags as file system paths. A
+// leading ~ or ~user in their values from the environment is expanded to
+// the home directory, as a shell would, and WithBaseDir applies to them.
+// Flags defined with Bind can be marked with BindOpts.Path instead.
+func WithPaths(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.paths[name] = true
+		}
+	}
+}
+
+// WithBaseDir resolves relative paths from the environment against dir, so
+// they don't depend on the working directory the program happens to be
+// started in. It is often the directory of a configuration file, or the
+// working directory at startup. Only the values of flags marked as paths,
+// with WithPaths or BindOpts.Path, are resolved. The Provenance of each
+// resolved flag records dir as its BaseDir.
+func WithBaseDir(dir string) Option {
+	return func(o *options) { o.baseDir = dir }
+}
+
+// isPath reports whether f's value is a path.
+func (o *options) isPath(f *flag.Flag) bool {
+	b, _ := binding(f)
+	return o.paths[f.Name] || b.Path
+}
+
+// WithPathLists marks the values of the named flags as lists of paths,
+// written the way PATH is on the current platform: separated by
+// os.PathListSeparator, which is a colon on Unix and a semicolon on Windows.
+// Each path is passed to the flag's Set method in turn, as with
+// WithSeparator, after being expanded and resolved as WithPaths describes.
+// Empty elements are passed to the flag as they are.
+func WithPathLists(names ...string) Option {
+	return func(o *options) {
+		for _, name := range names {
+			o.paths[name] = true
+			o.separators[name] = string(os.PathListSeparator)
+		}
+	}
+}
+
+// resolvePath expands a leading tilde in p's value, if it's a path, then
+// resolves it against the base directory if it's relative. When the flag
+// has a separator, each element of the value is resolved on its own.
+func (o *options) resolvePath(p *pending) error {
+	if !o.isPath(p.flag) || p.value == "" {
+		return nil
+	}
+	sep, ok := o.separators[p.flag.Name]
+	if !ok {
+		value, resolved, err := o.resolveOne(p.value)
+		if err != nil {
+			return err
+		}
+		p.value = value
+		if resolved {
+			p.baseDir = o.baseDir
+		}
+		return nil
+	}
+	elements := strings.Split(p.value, sep)
+	for i, element := range elements {
+		value, resolved, err := o.resolveOne(element)
+		if err != nil {
+			return err
+		}
+		elements[i] = value
+		if resolved {
+			p.baseDir = o.baseDir
+		}
+	}
+	p.value = strings.Join(elements, sep)
+	return nil
+}
+
+// resolveOne expands and resolves a single path, reporting whether it was
+// resolved against the base directory.
+func (o *options) resolveOne(path string) (string, bool, error) {
+	if path == "" {
+		return path, false, nil
+	}
+	path, err := expandTilde(path)
+	if err != nil {
+		return "", false, err
+	}
+	if o.baseDir != "" && !filepath.IsAbs(path) {
+		return filepath.Join(o.baseDir, path), true, nil
+	}
+	return path, false, nil
+}
+
+// expandTilde replaces a leading ~ in path with the current user's home
+// directory, and a leading ~user with that user's home directory, the way
+// a shell does. Other paths are returned as they are.
+func expandTilde(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	name, rest := path[1:], ""
+	if i := strings.IndexFunc(name, isSeparator); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to expand ~: %w", err)
+		}
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("unable to expand ~%v: %w", name, err)
+		}
+		home = u.HomeDir
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// isSeparator reports whether r separates the elements of a path.
+func isSeparator(r rune) bool {
+	return r == '/' || r == filepath.Separator
+}
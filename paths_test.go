@@ -0,0 +1,107 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOverrideWithBaseDir(t *testing.T) {
+
+	base := filepath.Join(t.TempDir(), "etc")
+	abs := filepath.Join(t.TempDir(), "cert.pem")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	data := fs.String("data", "", "")
+	cert := fs.String("cert", "", "")
+	name := fs.String("name", "", "")
+	var logs string
+	Bind(fs, &logs, "logs", "", "", BindOpts{Path: true})
+
+	var r Report
+	source := MapSource{"APP_DATA": "data", "APP_CERT": abs, "APP_NAME": "name", "APP_LOGS": "../logs"}
+	err := Override(fs, "APP_", WithSource(source), WithReport(&r), WithPaths("data", "cert"), WithBaseDir(base))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *data != filepath.Join(base, "data") || *cert != abs || *name != "name" || logs != filepath.Join(base, "..", "logs") {
+		t.Errorf("flags were data=%q, cert=%q, name=%q and logs=%q.", *data, *cert, *name, logs)
+	}
+
+	resolved := make(map[string]string)
+	for _, p := range r.Overridden {
+		resolved[p.Flag] = p.BaseDir
+	}
+	if resolved["data"] != base || resolved["logs"] != base || resolved["cert"] != "" || resolved["name"] != "" {
+		t.Errorf("Override reported base directories %v.", resolved)
+	}
+}
+
+func TestOverrideTildeExpansion(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	u, err := user.Current()
+	if err != nil || strings.ContainsAny(u.Username, `/\`) {
+		t.Skip("unable to find the current user, or a name to put after ~")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	data := fs.String("data", "", "")
+	other := fs.String("other", "", "")
+	config := fs.String("config", "", "")
+	name := fs.String("name", "", "")
+
+	source := MapSource{"APP_DATA": "~/data", "APP_OTHER": "~" + u.Username + "/x", "APP_CONFIG": "~", "APP_NAME": "~/name"}
+	if err := Override(fs, "APP_", WithSource(source), WithPaths("data", "other", "config")); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *data != filepath.Join(home, "data") || *config != home || *name != "~/name" {
+		t.Errorf("flags were data=%q, config=%q and name=%q.", *data, *config, *name)
+	}
+	if *other != filepath.Join(u.HomeDir, "x") {
+		t.Errorf("flag other was %q, want %q.", *other, filepath.Join(u.HomeDir, "x"))
+	}
+
+	source = MapSource{"APP_DATA": "~nosuchuserhopefully/data"}
+	if err := Override(fs, "APP_", WithSource(source), WithPaths("data")); err == nil {
+		t.Error("Override didn't return an error for an unknown user.")
+	}
+}
+
+func TestOverrideWithPathLists(t *testing.T) {
+
+	base := filepath.Join(t.TempDir(), "etc")
+	abs := filepath.Join(t.TempDir(), "plugins")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var dirs []string
+	fs.Func("plugin-path", "", func(s string) error {
+		dirs = append(dirs, s)
+		return nil
+	})
+
+	var r Report
+	sep := string(os.PathListSeparator)
+	source := MapSource{"APP_PLUGIN-PATH": abs + sep + "local" + sep}
+	err := Override(fs, "APP_", WithSource(source), WithReport(&r), WithPathLists("plugin-path"), WithBaseDir(base))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	want := []string{abs, filepath.Join(base, "local"), ""}
+	if strings.Join(dirs, "|") != strings.Join(want, "|") {
+		t.Errorf("plugin-path was set to %q, want %q.", dirs, want)
+	}
+	if len(r.Overridden) != 1 || r.Overridden[0].BaseDir != base {
+		t.Errorf("report was %+v.", r.Overridden)
+	}
+}
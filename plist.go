@@ -0,0 +1,134 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PlistSource returns a Source holding the top level dictionary of the XML
+// property list read from r. Keys are normalized the same way flag names are,
+// by converting them to upper case, so the key powerLevel sets the flag
+// powerlevel when Override is called with an empty prefix.
+// Strings, numbers, dates, and data are used as they are written in the file,
+// and booleans become "true" or "false". Nested dictionaries and arrays are
+// ignored. Binary property lists are not supported; convert them with
+// `plutil -convert xml1` first.
+func PlistSource(r io.Reader) (Source, error) {
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(6); bytes.Equal(magic, []byte("bplist")) {
+		return nil, errors.New("unable to read property list: binary property lists are not supported")
+	}
+
+	values, err := parsePlist(xml.NewDecoder(br))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read property list: %w", err)
+	}
+	return values, nil
+}
+
+// PlistFile returns a Source holding the property list in the file at path.
+// See PlistSource for how the file is interpreted.
+func PlistFile(path string) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := PlistSource(f)
+	if err != nil {
+		return nil, err
+	}
+	return namedSource{s.(MapSource), "plist:" + path}, nil
+}
+
+// parsePlist reads the top level dictionary of a property list.
+func parsePlist(d *xml.Decoder) (MapSource, error) {
+
+	// Skip ahead to the opening tag of the top level dictionary.
+	for {
+		start, err := nextStart(d)
+		if err != nil {
+			return nil, err
+		}
+		if start.Name.Local == "dict" {
+			break
+		}
+		if start.Name.Local != "plist" {
+			return nil, fmt.Errorf("top level element is %v, not dict", start.Name.Local)
+		}
+	}
+
+	values := make(MapSource)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			// The end of the top level dictionary.
+			return values, nil
+		case xml.StartElement:
+			if t.Name.Local != "key" {
+				return nil, fmt.Errorf("found %v where a key was expected", t.Name.Local)
+			}
+			var key string
+			if err := d.DecodeElement(&key, &t); err != nil {
+				return nil, err
+			}
+			value, ok, err := plistValue(d)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read value of key %v: %w", key, err)
+			}
+			if ok {
+				values[strings.ToUpper(key)] = value
+			}
+		}
+	}
+}
+
+// plistValue reads the next value from d, returning false if the value
+// isn't one which can be used to set a flag.
+func plistValue(d *xml.Decoder) (string, bool, error) {
+	start, err := nextStart(d)
+	if err != nil {
+		return "", false, err
+	}
+	switch start.Name.Local {
+	case "string", "integer", "real", "date", "data":
+		var v string
+		err := d.DecodeElement(&v, &start)
+		return strings.TrimSpace(v), err == nil, err
+	case "true", "false":
+		return start.Name.Local, true, d.Skip()
+	}
+	return "", false, d.Skip()
+}
+
+// nextStart returns the next opening tag from d.
+func nextStart(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return xml.StartElement{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+//go:build darwin
+
+package overridefromenv
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// DefaultsSource returns a Source holding the user defaults for domain,
+// such as com.example.myapp, as exported by `defaults export`.
+// The values are read once, when DefaultsSource is called.
+// See PlistSource for how keys and values are interpreted.
+func DefaultsSource(domain string) (Source, error) {
+	out, err := exec.Command("defaults", "export", domain, "-").Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to export defaults domain %v: %w", domain, err)
+	}
+	return PlistSource(bytes.NewReader(out))
+}
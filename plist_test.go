@@ -0,0 +1,77 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+const testPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>name</key>
+	<string>fromplist</string>
+	<key>powerLevel</key>
+	<integer>9000</integer>
+	<key>verbose</key>
+	<true/>
+	<key>nested</key>
+	<dict>
+		<key>ignored</key>
+		<string>value</string>
+	</dict>
+	<key>list</key>
+	<array><string>ignored</string></array>
+</dict>
+</plist>
+`
+
+func TestPlistSource(t *testing.T) {
+
+	s, err := PlistSource(strings.NewReader(testPlist))
+	if err != nil {
+		t.Fatalf("PlistSource returned an error: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	name := fs.String("name", "default", "")
+	power := fs.Int("powerlevel", 0, "")
+	verbose := fs.Bool("verbose", false, "")
+	nested := fs.String("nested", "default", "")
+
+	if err := Override(fs, "", WithSource(s)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *name != "fromplist" {
+		t.Error("string flag was not overwritten.")
+	}
+	if *power != 9000 {
+		t.Error("int flag was not overwritten.")
+	}
+	if *verbose != true {
+		t.Error("bool flag was not overwritten.")
+	}
+	if *nested != "default" {
+		t.Error("string flag was overwritten by a dictionary.")
+	}
+}
+
+func TestPlistSourceErrors(t *testing.T) {
+
+	for _, input := range []string{
+		"bplist00",
+		"<plist><array></array></plist>",
+		"<plist><dict><string>nokey</string></dict></plist>",
+		"<plist><dict><key>truncated</key>",
+	} {
+		if _, err := PlistSource(strings.NewReader(input)); err == nil {
+			t.Errorf("PlistSource(%q) didn't return an error.", input)
+		}
+	}
+}
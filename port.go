@@ -0,0 +1,29 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+)
+
+// WithPortVar sets the flag called name from the PORT variable, without the
+// prefix, which Heroku, Cloud Run, App Engine and other platforms set to
+// the port a service must listen on. The flag's own variable and the
+// command line take precedence, while giving the flag to WithEnvPrecedence
+// as well makes the platform's port win over arguments baked into a
+// container's command:
+//
+//	Override(fs, "APP_", WithPortVar("port"), WithEnvPrecedence("port"))
+func WithPortVar(name string) Option {
+	return func(o *options) {
+		o.composites = append(o.composites, composite{
+			name: func(string) string { return "PORT" },
+			split: func(_ *flag.FlagSet, value string) ([]part, error) {
+				return []part{{flag: name, value: value}}, nil
+			},
+		})
+	}
+}
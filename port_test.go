@@ -0,0 +1,47 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestWithPortVar(t *testing.T) {
+
+	for _, test := range []struct {
+		env  MapSource
+		args []string
+		opts []Option
+		want int
+	}{
+		{MapSource{}, nil, nil, 8080},
+		{MapSource{"PORT": "5000"}, nil, nil, 5000},
+		{MapSource{"PORT": "5000", "APP_PORT": "7777"}, nil, nil, 7777},
+		{MapSource{"PORT": "5000"}, []string{"-port", "9000"}, nil, 9000},
+		{MapSource{"PORT": "5000"}, []string{"-port", "9000"}, []Option{WithEnvPrecedence("port")}, 5000},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		port := fs.Int("port", 8080, "")
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		opts := append([]Option{WithSource(test.env), WithPortVar("port")}, test.opts...)
+		if err := Override(fs, "APP_", opts...); err != nil {
+			t.Errorf("Override returned an error for %v: %v", test.env, err)
+			continue
+		}
+		if *port != test.want {
+			t.Errorf("with %v and %q, port was %v, want %v.", test.env, test.args, *port, test.want)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 8080, "")
+	if err := Override(fs, "APP_", WithSource(MapSource{"PORT": "http"}), WithPortVar("port")); err == nil {
+		t.Error("Override didn't return an error for a PORT which isn't a number.")
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+)
+
+// The flag and environment variable which PrefixFlag uses to choose the prefix.
+const (
+	PrefixFlagName = "env-prefix"
+	PrefixVar      = "ENV_PREFIX"
+)
+
+// PrefixFlag defines a flag called env-prefix in fs, which chooses the
+// prefix for the other flags at runtime, so one binary can be run under
+// several naming schemes. The returned function gives the prefix, once fs
+// has been parsed: the value of -env-prefix if it was set, otherwise the
+// value of the ENV_PREFIX environment variable if it exists, and otherwise
+// def.
+//
+//	prefix := overridefromenv.PrefixFlag(fs, "APP_")
+//	fs.Parse(os.Args[1:])
+//	err := overridefromenv.Override(fs, prefix())
+//
+// The flag is bound to ENV_PREFIX, so Override never reads it from a
+// prefixed variable.
+func PrefixFlag(fs *flag.FlagSet, def string) func() string {
+	var prefix string
+	Bind(fs, &prefix, PrefixFlagName, def,
+		"the prefix of the environment variables for the other flags", BindOpts{Env: PrefixVar})
+	return func() string {
+		if setFlags(fs)[PrefixFlagName] {
+			return prefix
+		}
+		if value, found := Environment.Lookup(PrefixVar); found {
+			return value
+		}
+		return def
+	}
+}
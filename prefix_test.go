@@ -0,0 +1,50 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestPrefixFlag(t *testing.T) {
+
+	// Setenv restores the variable once the test is done.
+	t.Setenv(PrefixVar, "")
+	os.Unsetenv(PrefixVar)
+	tests := []struct {
+		args []string
+		env  string
+		want string
+	}{
+		{nil, "", "APP_"},
+		{nil, "OTHER_", "OTHER_"},
+		{[]string{"-env-prefix", "FLAG_"}, "OTHER_", "FLAG_"},
+	}
+	for _, test := range tests {
+		if test.env != "" {
+			t.Setenv(PrefixVar, test.env)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		port := fs.Int("port", 80, "")
+		prefix := PrefixFlag(fs, "APP_")
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatalf("Parse returned an error: %v", err)
+		}
+		if got := prefix(); got != test.want {
+			t.Errorf("the prefix for %q with %v=%q was %q, want %q.", test.args, PrefixVar, test.env, got, test.want)
+		}
+
+		source := MapSource{test.want + "PORT": "8080", test.want + "ENV_PREFIX": "WRONG_"}
+		if err := Override(fs, prefix(), WithSource(source)); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+		if *port != 8080 {
+			t.Errorf("flag port was %v, want 8080.", *port)
+		}
+	}
+}
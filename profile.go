@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"strings"
+)
+
+// WithProfile selects a deployment profile, like dev, staging or prod, with
+// the variable named prefix+selector. With the prefix APP_ and the selector
+// ENV, setting APP_ENV=staging makes the flag port read APP_STAGING_PORT,
+// if it exists, before APP_PORT, so one environment file can hold the
+// values for several deployment targets. The profile takes precedence over
+// any scopes. If the selector variable isn't set, or is empty, no profile
+// is used. The selector variable is never reported by Unused.
+func WithProfile(selector string) Option {
+	return func(o *options) { o.profileSelector = selector }
+}
+
+// profile returns the scope for the selected profile, or the empty string if
+// there isn't one. The selector is only looked up once.
+func (o *options) profile(prefix string) string {
+	if o.profileSelector == "" {
+		return ""
+	}
+	if o.profileScope == nil {
+		scope := ""
+		if name, found := o.source.Lookup(o.profileVar(prefix)); found && name != "" {
+			scope = strings.ToUpper(name) + "_"
+		}
+		o.profileScope = &scope
+	}
+	return *o.profileScope
+}
+
+// profileVar returns the name of the variable which selects the profile.
+func (o *options) profileVar(prefix string) string {
+	return VarName(prefix, o.profileSelector)
+}
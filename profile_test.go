@@ -0,0 +1,52 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestOverrideWithProfile(t *testing.T) {
+
+	source := MapSource{
+		"APP_ENV":          "staging",
+		"APP_PORT":         "8080",
+		"APP_STAGING_PORT": "8081",
+		"APP_PROD_PORT":    "80",
+		"APP_SERVE_HOST":   "serve.example.com",
+		"APP_STAGING_HOST": "staging.example.com",
+		"APP_NAME":         "app",
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 0, "")
+	host := fs.String("host", "", "")
+	name := fs.String("name", "", "")
+	opts := []Option{WithSource(source), WithScopes("SERVE_"), WithProfile("ENV")}
+	if err := Override(fs, "APP_", opts...); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8081 || *host != "staging.example.com" || *name != "app" {
+		t.Errorf("flags were port=%v, host=%q and name=%q.", *port, *host, *name)
+	}
+	if unused := Unused(fs, "APP_", opts...); !reflect.DeepEqual(unused, []string{"APP_PROD_PORT"}) {
+		t.Errorf("Unused returned %v.", unused)
+	}
+
+	// Without a profile, the scopes and the prefix are used as usual.
+	delete(source, "APP_ENV")
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	port = fs.Int("port", 0, "")
+	host = fs.String("host", "", "")
+	if err := Override(fs, "APP_", opts...); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || *host != "serve.example.com" {
+		t.Errorf("flags were port=%v and host=%q.", *port, *host)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+)
+
+// ProxyFlags names the flags which WithProxyVars sets. Empty names are
+// ignored.
+type ProxyFlags struct {
+	HTTP    string // Set from HTTP_PROXY or http_proxy.
+	HTTPS   string // Set from HTTPS_PROXY or https_proxy.
+	NoProxy string // Set from NO_PROXY or no_proxy.
+}
+
+// WithProxyVars sets the named flags from the standard proxy variables,
+// which are used as they are, without the prefix, so a service can have
+// flags for its proxy while still honouring the variables every other tool
+// reads. The precedence is the same as http.ProxyFromEnvironment's: the
+// upper case name wins over the lower case one, empty variables are
+// ignored, and HTTP_PROXY is ignored when REQUEST_METHOD is set, since a
+// CGI program's HTTP_PROXY comes from the Proxy header of the request. The
+// flags' own variables and the command line take precedence.
+func WithProxyVars(flags ProxyFlags) Option {
+	return func(o *options) {
+		for _, v := range []struct{ name, flag string }{
+			{"HTTP_PROXY", flags.HTTP},
+			{"http_proxy", flags.HTTP},
+			{"HTTPS_PROXY", flags.HTTPS},
+			{"https_proxy", flags.HTTPS},
+			{"NO_PROXY", flags.NoProxy},
+			{"no_proxy", flags.NoProxy},
+		} {
+			if v.flag == "" {
+				continue
+			}
+			o.composites = append(o.composites, composite{
+				name: func(string) string { return v.name },
+				split: func(_ *flag.FlagSet, value string) ([]part, error) {
+					if value == "" {
+						return nil, nil
+					}
+					if v.name == "HTTP_PROXY" {
+						if _, cgi := o.source.Lookup("REQUEST_METHOD"); cgi {
+							return nil, nil
+						}
+					}
+					return []part{{flag: v.flag, value: value}}, nil
+				},
+			})
+		}
+	}
+}
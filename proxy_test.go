@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestWithProxyVars(t *testing.T) {
+
+	for _, test := range []struct {
+		env                  MapSource
+		http, https, noProxy string
+	}{
+		{MapSource{}, "", "", ""},
+		{MapSource{"http_proxy": "lower", "HTTPS_PROXY": "upper", "no_proxy": "localhost"}, "lower", "upper", "localhost"},
+		{MapSource{"HTTP_PROXY": "upper", "http_proxy": "lower"}, "upper", "", ""},
+		{MapSource{"HTTP_PROXY": "", "http_proxy": "lower"}, "lower", "", ""},
+		{MapSource{"HTTP_PROXY": "header", "REQUEST_METHOD": "GET"}, "", "", ""},
+		{MapSource{"http_proxy": "lower", "REQUEST_METHOD": "GET"}, "lower", "", ""},
+		{MapSource{"HTTPS_PROXY": "upper", "APP_HTTPS-PROXY": "own"}, "", "own", ""},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		http := fs.String("http-proxy", "", "")
+		https := fs.String("https-proxy", "", "")
+		noProxy := fs.String("no-proxy", "", "")
+		flags := ProxyFlags{HTTP: "http-proxy", HTTPS: "https-proxy", NoProxy: "no-proxy"}
+		if err := Override(fs, "APP_", WithSource(test.env), WithProxyVars(flags)); err != nil {
+			t.Errorf("Override returned an error for %v: %v", test.env, err)
+			continue
+		}
+		if *http != test.http || *https != test.https || *noProxy != test.noProxy {
+			t.Errorf("with %v, flags were %q, %q and %q.", test.env, *http, *https, *noProxy)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"fmt"
+)
+
+// A RedisClient runs the HGETALL, SCAN and GET commands the Redis sources
+// need.
+type RedisClient interface {
+	// HGetAll returns the fields and values of the hash stored at key.
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	// Scan returns all the keys matching the glob style pattern, using SCAN.
+	Scan(ctx context.Context, match string) ([]string, error)
+	// Get returns the value of key, and whether it exists.
+	Get(ctx context.Context, key string) (string, bool, error)
+}
+
+// RedisHashSource returns a Source holding the fields of the hash stored
+// at key in Redis, so configuration kept in Redis for each environment can
+// set unset flags. The field names are the variable names, like APP_PORT.
+func RedisHashSource(ctx context.Context, client RedisClient, key string) (Source, error) {
+	return FetchSource(ctx, "redis:"+key, func(ctx context.Context) (map[string]string, error) {
+		return client.HGetAll(ctx, key)
+	})
+}
+
+// RedisKeysSource returns a Source holding the Redis keys which start with
+// prefix, with their values, so the flag port is set from the key APP_PORT
+// with the prefix APP_. Keys which are deleted between the SCAN and their
+// GET are left out.
+func RedisKeysSource(ctx context.Context, client RedisClient, prefix string) (Source, error) {
+	return FetchSource(ctx, "redis:"+prefix+"*", func(ctx context.Context) (map[string]string, error) {
+		keys, err := client.Scan(ctx, redisEscape(prefix)+"*")
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]string, len(keys))
+		for _, key := range keys {
+			value, ok, err := client.Get(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get key %v: %w", key, err)
+			}
+			if ok {
+				values[key] = value
+			}
+		}
+		return values, nil
+	})
+}
+
+// redisEscape escapes the characters which are special in a SCAN pattern.
+func redisEscape(s string) string {
+	var escaped []rune
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\', '^':
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, r)
+	}
+	return string(escaped)
+}
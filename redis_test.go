@@ -0,0 +1,82 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"path"
+	"strings"
+	"testing"
+)
+
+// fakeRedis holds string keys, and hashes under keys starting with hash:.
+type fakeRedis map[string]string
+
+func (r fakeRedis) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for k, v := range r {
+		if field, ok := strings.CutPrefix(k, "hash:"+key+":"); ok {
+			fields[field] = v
+		}
+	}
+	return fields, nil
+}
+
+func (r fakeRedis) Scan(ctx context.Context, match string) ([]string, error) {
+	var keys []string
+	for k := range r {
+		// path.Match is close enough to Redis patterns for these tests.
+		if ok, _ := path.Match(match, k); ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (r fakeRedis) Get(ctx context.Context, key string) (string, bool, error) {
+	if key == "APP_BROKEN" {
+		return "", false, errors.New("connection reset")
+	}
+	v, ok := r[key]
+	return v, ok, nil
+}
+
+func TestRedisSources(t *testing.T) {
+
+	redis := fakeRedis{"APP_PORT": "8080", "OTHER_PORT": "1", "hash:staging:APP_PORT": "8081"}
+
+	tests := []struct {
+		open func() (Source, error)
+		want int
+	}{
+		{func() (Source, error) { return RedisKeysSource(context.Background(), redis, "APP_") }, 8080},
+		{func() (Source, error) { return RedisHashSource(context.Background(), redis, "staging") }, 8081},
+	}
+	for _, test := range tests {
+		s, err := test.open()
+		if err != nil {
+			t.Fatalf("the Redis source returned an error: %v", err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		port := fs.Int("port", 80, "")
+		if err := Override(fs, "APP_", WithSource(s)); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+		if *port != test.want {
+			t.Errorf("flag port was %v, want %v.", *port, test.want)
+		}
+	}
+
+	redis["APP_BROKEN"] = "x"
+	if _, err := RedisKeysSource(context.Background(), redis, "APP_"); err == nil {
+		t.Error("RedisKeysSource didn't return an error when a key couldn't be read.")
+	}
+	if got := redisEscape("a*b?[c]"); got != `a\*b\?\[c\]` {
+		t.Errorf("redisEscape returned %q.", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A SourceFactory opens the Source described by a URL.
+type SourceFactory func(u *url.URL) (Source, error)
+
+// factories holds the registered SourceFactories, by scheme.
+var factories = struct {
+	sync.RWMutex
+	m map[string]SourceFactory
+}{m: make(map[string]SourceFactory)}
+
+func init() {
+	RegisterSource("env", func(*url.URL) (Source, error) { return Environment, nil })
+	RegisterSource("file", openFile)
+}
+
+// RegisterSource makes a SourceFactory available to OpenSource for URLs with
+// the given scheme, which is compared without regard to case. Packages
+// providing sources usually call it from an init function.
+// If RegisterSource is called twice with the same scheme, or if factory is
+// nil, it panics.
+//
+// The env scheme, for the process environment, and the file scheme, for
+// dotenv and property list files, are registered by this package.
+func RegisterSource(scheme string, factory SourceFactory) {
+	factories.Lock()
+	defer factories.Unlock()
+	scheme = strings.ToLower(scheme)
+	if factory == nil {
+		panic("overridefromenv: RegisterSource factory is nil")
+	}
+	if _, dup := factories.m[scheme]; dup {
+		panic("overridefromenv: RegisterSource called twice for scheme " + scheme)
+	}
+	factories.m[scheme] = factory
+}
+
+// Schemes returns a sorted list of the schemes of the registered sources.
+func Schemes() []string {
+	factories.RLock()
+	defer factories.RUnlock()
+	schemes := make([]string, 0, len(factories.m))
+	for scheme := range factories.m {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// OpenSource opens the Source described by rawURL, such as env:// or
+// file:///etc/myapp/config.env, using the factory registered for its scheme.
+func OpenSource(rawURL string) (Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open source: %w", err)
+	}
+	factories.RLock()
+	factory, ok := factories.m[strings.ToLower(u.Scheme)]
+	factories.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unable to open source %v: no source registered for scheme %q", rawURL, u.Scheme)
+	}
+	s, err := factory(u)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open source %v: %w", rawURL, err)
+	}
+	return s, nil
+}
+
+// openFile opens a file URL. Files ending in .plist are read as property
+// lists, and all others as dotenv files.
+func openFile(u *url.URL) (Source, error) {
+	path := u.Path
+	if u.Opaque != "" {
+		path = u.Opaque
+	}
+	if path == "" {
+		return nil, fmt.Errorf("file URL has no path")
+	}
+	path = filepath.FromSlash(path)
+	if strings.EqualFold(filepath.Ext(path), ".plist") {
+		return PlistFile(path)
+	}
+	return DotenvFile(path)
+}
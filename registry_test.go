@@ -0,0 +1,87 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestOpenSource(t *testing.T) {
+
+	RegisterSource("registrytest", func(u *url.URL) (Source, error) {
+		return MapSource{"KEY": u.Host}, nil
+	})
+	s, err := OpenSource("RegistryTest://example.com")
+	if err != nil {
+		t.Fatalf("OpenSource returned an error: %v", err)
+	}
+	if v, ok := s.Lookup("KEY"); !ok || v != "example.com" {
+		t.Errorf("Lookup returned %q, %v.", v, ok)
+	}
+
+	if s, err := OpenSource("env://"); err != nil || s != Environment {
+		t.Errorf("OpenSource(\"env://\") returned %v, %v.", s, err)
+	}
+
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, "config.env")
+	os.WriteFile(dotenv, []byte("APP_NAME=fromfile\n"), 0600)
+	plist := filepath.Join(dir, "config.plist")
+	os.WriteFile(plist, []byte(testPlist), 0600)
+
+	for path, key := range map[string]string{dotenv: "APP_NAME", plist: "NAME"} {
+		s, err := OpenSource((&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String())
+		if err != nil {
+			t.Fatalf("OpenSource returned an error: %v", err)
+		}
+		if _, ok := s.Lookup(key); !ok {
+			t.Errorf("The source for %v didn't hold %v.", path, key)
+		}
+	}
+
+	for _, bad := range []string{"nosuchscheme://x", "file://", "%zz"} {
+		if _, err := OpenSource(bad); err == nil {
+			t.Errorf("OpenSource(%q) didn't return an error.", bad)
+		}
+	}
+}
+
+func TestRegisterSourcePanics(t *testing.T) {
+
+	for _, register := range []func(){
+		func() { RegisterSource("env", func(*url.URL) (Source, error) { return nil, nil }) },
+		func() { RegisterSource("nilfactory", nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("RegisterSource didn't panic.")
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestSchemes(t *testing.T) {
+	schemes := Schemes()
+	for _, want := range []string{"env", "file"} {
+		found := false
+		for _, s := range schemes {
+			found = found || s == want
+		}
+		if !found {
+			t.Errorf("Schemes returned %q, which doesn't include %v.", schemes, want)
+		}
+	}
+	if !sort.StringsAreSorted(schemes) {
+		t.Errorf("Schemes returned %q, which isn't sorted.", schemes)
+	}
+}
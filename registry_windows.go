@@ -0,0 +1,126 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package overridefromenv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+)
+
+// registryRoots maps the names of the predefined registry keys,
+// in long and abbreviated form, to their handles.
+var registryRoots = map[string]syscall.Handle{
+	"HKEY_CLASSES_ROOT":   syscall.HKEY_CLASSES_ROOT,
+	"HKCR":                syscall.HKEY_CLASSES_ROOT,
+	"HKEY_CURRENT_USER":   syscall.HKEY_CURRENT_USER,
+	"HKCU":                syscall.HKEY_CURRENT_USER,
+	"HKEY_LOCAL_MACHINE":  syscall.HKEY_LOCAL_MACHINE,
+	"HKLM":                syscall.HKEY_LOCAL_MACHINE,
+	"HKEY_USERS":          syscall.HKEY_USERS,
+	"HKU":                 syscall.HKEY_USERS,
+	"HKEY_CURRENT_CONFIG": syscall.HKEY_CURRENT_CONFIG,
+	"HKCC":                syscall.HKEY_CURRENT_CONFIG,
+}
+
+// registrySource looks up values stored under a registry key.
+type registrySource struct {
+	root   syscall.Handle
+	subkey string
+}
+
+// RegistrySource returns a Source which reads the values stored under the
+// registry key at path, such as `HKLM\SOFTWARE\MyApp`.
+// Value names are compared without regard to case, so the value PowerLevel
+// sets the flag powerlevel when Override is called with an empty prefix.
+// String values are used as they are, without expanding any references to
+// environment variables, and DWORD and QWORD values are formatted in decimal.
+// Values of any other type are treated as missing.
+// The key is opened again on every lookup. An error is returned if the key
+// can't be opened now.
+func RegistrySource(path string) (Source, error) {
+
+	rootName, subkey := path, ""
+	if i := strings.Index(path, `\`); i != -1 {
+		rootName, subkey = path[:i], path[i+1:]
+	}
+	root, ok := registryRoots[strings.ToUpper(rootName)]
+	if !ok {
+		return nil, fmt.Errorf("unable to open registry key %v: unknown root key %v", path, rootName)
+	}
+
+	s := &registrySource{root: root, subkey: subkey}
+	key, err := s.open()
+	if err != nil {
+		return nil, fmt.Errorf("unable to open registry key %v: %w", path, err)
+	}
+	syscall.RegCloseKey(key)
+	return s, nil
+}
+
+// open opens the registry key for reading.
+func (s *registrySource) open() (syscall.Handle, error) {
+	subkey, err := syscall.UTF16PtrFromString(s.subkey)
+	if err != nil {
+		return 0, err
+	}
+	var key syscall.Handle
+	err = syscall.RegOpenKeyEx(s.root, subkey, 0, syscall.KEY_READ, &key)
+	return key, err
+}
+
+// Lookup reads the registry value called name.
+func (s *registrySource) Lookup(name string) (string, bool) {
+
+	key, err := s.open()
+	if err != nil {
+		return "", false
+	}
+	defer syscall.RegCloseKey(key)
+
+	valueName, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return "", false
+	}
+
+	// Ask for the size of the value first, then read it.
+	// The buffer has room for a terminating NUL, which the
+	// registry doesn't guarantee is stored with string values.
+	var valueType, size uint32
+	err = syscall.RegQueryValueEx(key, valueName, nil, &valueType, nil, &size)
+	if err != nil {
+		return "", false
+	}
+	buf := make([]byte, size+2)
+	err = syscall.RegQueryValueEx(key, valueName, nil, &valueType, &buf[0], &size)
+	if err != nil {
+		return "", false
+	}
+	buf = buf[:size]
+
+	switch valueType {
+	case syscall.REG_SZ, syscall.REG_EXPAND_SZ:
+		u := make([]uint16, len(buf)/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(buf[2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(u)), "\x00"), true
+	case syscall.REG_DWORD:
+		if len(buf) == 4 {
+			return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(buf)), 10), true
+		}
+	case syscall.REG_QWORD:
+		if len(buf) == 8 {
+			return strconv.FormatUint(binary.LittleEndian.Uint64(buf), 10), true
+		}
+	}
+	return "", false
+}
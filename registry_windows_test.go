@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestRegistrySource(t *testing.T) {
+
+	_, err := RegistrySource(`HKNOPE\SOFTWARE`)
+	if err == nil {
+		t.Error("An unknown root key didn't cause an error.")
+	}
+	_, err = RegistrySource(`HKLM\SOFTWARE\OverrideFromEnvDoesNotExist`)
+	if err == nil {
+		t.Error("A missing key didn't cause an error.")
+	}
+
+	s, err := RegistrySource(`HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`)
+	if err != nil {
+		t.Fatalf("RegistrySource returned an error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	build := fs.String("currentbuildnumber", "", "")
+	fs.String("overridefromenvdoesnotexist", "default", "")
+
+	if err := Override(fs, "", WithSource(s)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *build == "" {
+		t.Error("string flag was not overwritten from the registry.")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// A FetchFunc fetches all the values held by a remote configuration
+// service, keyed by variable name, for FetchSource. The remote sources,
+// like DopplerSource and ZooKeeperSource, are built on one: each fetches its
+// values once, when it is created, so lookups never wait on the network,
+// and later changes aren't seen until the source is created again. The package doesn't depend
+// on any client library, so the sources which need a client take a small
+// interface or function, which the application implements with its own.
+type FetchFunc func(ctx context.Context) (map[string]string, error)
+
+// A FetchResult describes a fetch by a remote source.
+type FetchResult struct {
+	Source   string        // The name of the source, as in provenance.
+	Keys     int           // The number of values fetched.
+	Duration time.Duration // How long the fetch took.
+	Err      error         // The error, if the fetch failed.
+}
+
+// A FetchHook is called when a remote source starts fetching its values,
+// with the context it was given and the name of the source. It returns the
+// context to fetch with, and a function which is called with the result
+// when the fetch is done. With OpenTelemetry, for example, the hook starts
+// a span, returns the context holding it, and ends it with the result's
+// attributes and error, so slow startups are visible in traces:
+//
+//	ctx = WithFetchHook(ctx, func(ctx context.Context, source string) (context.Context, func(FetchResult)) {
+//		ctx, span := tracer.Start(ctx, "fetch "+source)
+//		return ctx, func(r FetchResult) {
+//			span.SetAttributes(attribute.Int("config.keys", r.Keys))
+//			if r.Err != nil {
+//				span.RecordError(r.Err)
+//			}
+//			span.End()
+//		}
+//	})
+type FetchHook func(ctx context.Context, source string) (context.Context, func(FetchResult))
+
+// fetchHookKey is the context key for the FetchHook.
+type fetchHookKey struct{}
+
+// WithFetchHook returns a copy of ctx holding hook, which the remote sources
+// given the context call around their fetches.
+func WithFetchHook(ctx context.Context, hook FetchHook) context.Context {
+	return context.WithValue(ctx, fetchHookKey{}, hook)
+}
+
+// FetchSource fetches the values with fetch, and returns them as a Source
+// called name, the way the package's remote sources do, so a source for a
+// service the package doesn't know about calls the FetchHook in ctx and
+// records where its values came from in the same way:
+//
+//	src, err := FetchSource(ctx, "etcd:/app", func(ctx context.Context) (map[string]string, error) {
+//		return readEtcd(ctx, client, "/app")
+//	})
+func FetchSource(ctx context.Context, name string, fetch FetchFunc) (Source, error) {
+	s, err := fetchNamed(ctx, name, fetch)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fetchSecretSource is like FetchSource, but the values are all secrets.
+func fetchSecretSource(ctx context.Context, name string, fetch FetchFunc) (Source, error) {
+	s, err := fetchNamed(ctx, name, fetch)
+	if err != nil {
+		return nil, err
+	}
+	return secretSource{s}, nil
+}
+
+// fetchNamed fetches the values with fetch, and returns them as a
+// namedSource called name.
+func fetchNamed(ctx context.Context, name string, fetch FetchFunc) (namedSource, error) {
+
+	var done func(FetchResult)
+	if hook, ok := ctx.Value(fetchHookKey{}).(FetchHook); ok && hook != nil {
+		ctx, done = hook(ctx, name)
+	}
+	start := now()
+	values, err := fetch(ctx)
+	if done != nil {
+		done(FetchResult{Source: name, Keys: len(values), Duration: now().Sub(start), Err: err})
+	}
+	if err != nil {
+		return namedSource{}, fmt.Errorf("unable to fetch values from %v: %w", name, err)
+	}
+	return namedSource{MapSource(values), name}, nil
+}
+
+// getJSON sends req with client, and decodes the JSON response into v,
+// keeping numbers as they were written. It is an error for the response to
+// have a status other than 200 OK.
+func getJSON(client *http.Client, req *http.Request, v any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v returned %v", req.URL.Redacted(), resp.Status)
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("unable to decode the response from %v: %w", req.URL.Redacted(), err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFetchHook(t *testing.T) {
+
+	clock := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	type spanKey struct{}
+	var results []FetchResult
+	ctx := WithFetchHook(context.Background(), func(ctx context.Context, source string) (context.Context, func(FetchResult)) {
+		return context.WithValue(ctx, spanKey{}, source), func(r FetchResult) { results = append(results, r) }
+	})
+
+	_, err := FetchSource(ctx, "test", func(ctx context.Context) (map[string]string, error) {
+		if ctx.Value(spanKey{}) != "test" {
+			t.Error("the fetch wasn't given the hook's context.")
+		}
+		clock = clock.Add(time.Second)
+		return map[string]string{"APP_PORT": "8080", "APP_HOST": "localhost"}, nil
+	})
+	if err != nil {
+		t.Fatalf("FetchSource returned an error: %v", err)
+	}
+	errFetch := errors.New("unavailable")
+	if _, err := FetchSource(ctx, "broken", func(ctx context.Context) (map[string]string, error) {
+		return nil, errFetch
+	}); err == nil {
+		t.Error("FetchSource didn't return an error when the fetch failed.")
+	}
+
+	want := []FetchResult{{Source: "test", Keys: 2, Duration: time.Second}, {Source: "broken", Err: errFetch}}
+	if len(results) != len(want) {
+		t.Fatalf("the hook was given %+v, want %+v.", results, want)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("the hook was given %+v, want %+v.", results[i], want[i])
+		}
+	}
+
+	if _, err := FetchSource(context.Background(), "test", func(ctx context.Context) (map[string]string, error) {
+		return nil, nil
+	}); err != nil || len(results) != 2 {
+		t.Error("the hook was called for a context without it.")
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+)
+
+// WithRenamed declares that the flag newName used to be called oldName, so
+// the variables for the old name still set it while deployments catch up
+// with the rename. With the prefix APP_, after renaming the flag timeout to
+// read-timeout, APP_TIMEOUT still sets read-timeout if APP_READ-TIMEOUT
+// isn't set, and a warning names the variable to use instead. A flag can
+// have several old names, which are tried in the order given.
+func WithRenamed(oldName, newName string) Option {
+	return func(o *options) {
+		if o.renamed == nil {
+			o.renamed = make(map[string][]string)
+		}
+		o.renamed[newName] = append(o.renamed[newName], oldName)
+	}
+}
+
+// warnRenamed warns if name is one of the variables for an old name of f.
+func (o *options) warnRenamed(fs *flag.FlagSet, prefix string, f *flag.Flag, name string) {
+	if len(o.renamed[f.Name]) == 0 {
+		return
+	}
+	current := o.scopedNames(fs, prefix, f.Name)
+	for _, old := range o.renamed[f.Name] {
+		for i, n := range o.scopedNames(fs, prefix, old) {
+			if n == name {
+				o.warn(Warning{Flag: f.Name, Var: name, Message: fmt.Sprintf(
+					"deprecated, as flag %v was renamed to %v, set %v instead", old, f.Name, current[i])})
+				return
+			}
+		}
+	}
+}
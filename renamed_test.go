@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestWithRenamed(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.Int("read-timeout", 10, "")
+	port := fs.Int("port", 80, "")
+
+	var r Report
+	env := MapSource{"APP_TIMEOUT": "30", "APP_PORT": "7777", "APP_LISTEN": "1"}
+	opts := []Option{WithSource(env), WithReport(&r), WithRenamed("timeout", "read-timeout"), WithRenamed("listen", "port")}
+	if err := Override(fs, "APP_", opts...); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *timeout != 30 || *port != 7777 {
+		t.Errorf("flags were %v and %v, want 30 and 7777.", *timeout, *port)
+	}
+	want := []Warning{{Flag: "read-timeout", Var: "APP_TIMEOUT",
+		Message: "deprecated, as flag timeout was renamed to read-timeout, set APP_READ-TIMEOUT instead"}}
+	if !reflect.DeepEqual(r.Warnings, want) {
+		t.Errorf("warnings were %v, want %v.", r.Warnings, want)
+	}
+
+	got := CandidateNames(fs, "APP_", WithRenamed("timeout", "read-timeout"), WithScopes("SERVE_"))["read-timeout"]
+	names := []string{"APP_SERVE_READ-TIMEOUT", "APP_READ-TIMEOUT", "APP_SERVE_TIMEOUT", "APP_TIMEOUT"}
+	if !reflect.DeepEqual(got, names) {
+		t.Errorf("read-timeout was read from %q, want %q.", got, names)
+	}
+}
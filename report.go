@@ -0,0 +1,113 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Report records what an Override call did.
+type Report struct {
+	// Overridden holds one entry for each flag which was set,
+	// in the order the flags were set.
+	Overridden []Provenance
+
+	// Missing holds the names of the variables Override looked for but
+	// didn't find, in the order they were looked for. Flags which were
+	// skipped, because they were already set for example, aren't looked up.
+	Missing []string
+
+	// Warnings holds the problems Override found which didn't stop it,
+	// in the order they were found.
+	Warnings []Warning
+}
+
+// A Warning describes a problem which isn't serious enough to be an error,
+// like a variable which is ignored.
+type Warning struct {
+	Flag    string // The name of the flag, if the warning is about one.
+	Var     string // The name of the variable.
+	Message string // What the problem is.
+}
+
+// String describes w on a single line.
+func (w Warning) String() string {
+	if w.Flag == "" {
+		return fmt.Sprintf("environment variable %v: %v", w.Var, w.Message)
+	}
+	return fmt.Sprintf("flag %v, environment variable %v: %v", w.Flag, w.Var, w.Message)
+}
+
+// Provenance describes where the value of an overridden flag came from.
+type Provenance struct {
+	Flag  string // The name of the flag.
+	Var   string // The variable the value was read from.
+	Value string // The value passed to the flag's Set method, unless it's a secret.
+
+	// Secret is true if the value is a secret, because the flag was bound
+	// with Secret or the value was decrypted for example, so Value and
+	// Previous are redacted.
+	Secret bool
+
+	// Replaced is true if the flag had already been set, from the
+	// command line for example, and the environment took precedence.
+	Replaced bool
+	// Previous is the value the flag had before it was replaced.
+	// Like Value, it is redacted for secret flags.
+	Previous string
+
+	// Source describes the Source the value was found in. For a
+	// SourceNamer, like a CompositeSource, it describes the source within
+	// it which held the variable.
+	Source string
+	Time   time.Time // When the flag was set.
+
+	// BaseDir is the directory the value was resolved against, if it was a
+	// relative path. See WithBaseDir.
+	BaseDir string
+}
+
+// reset clears r. It is safe to call on a nil Report.
+func (r *Report) reset() {
+	if r != nil {
+		*r = Report{}
+	}
+}
+
+// add records p in r. It is safe to call on a nil Report.
+func (r *Report) add(p Provenance) {
+	if r != nil {
+		r.Overridden = append(r.Overridden, p)
+	}
+}
+
+// miss records that the variable called name wasn't found.
+// It is safe to call on a nil Report.
+func (r *Report) miss(name string) {
+	if r != nil {
+		r.Missing = append(r.Missing, name)
+	}
+}
+
+// warn records w in r. It is safe to call on a nil Report.
+func (r *Report) warn(w Warning) {
+	if r != nil {
+		r.Warnings = append(r.Warnings, w)
+	}
+}
+
+// sourceName describes s for a Provenance: env for the environment, the
+// result of its String method if it has one, and otherwise its type.
+func sourceName(s Source) string {
+	switch s := s.(type) {
+	case envSource, environSnapshot:
+		return "env"
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprintf("%T", s)
+}
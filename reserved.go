@@ -0,0 +1,55 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ErrReserved is wrapped by the error Override returns when one of the
+// variables it would read for a flag is reserved with WithReserved.
+var ErrReserved = errors.New("environment variable is reserved")
+
+// WithReserved declares variables which must never be read as overrides,
+// like those owned by the platform or covered by compliance rules. Override
+// returns an error wrapping ErrReserved if any of the names it would try
+// for any flag in the FlagSet is reserved, whether or not the variable is
+// set, so the mistake is caught before it matters. Names are compared
+// without regard to case.
+func WithReserved(names ...string) Option {
+	return func(o *options) {
+		if o.reserved == nil {
+			o.reserved = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.reserved[strings.ToUpper(name)] = true
+		}
+	}
+}
+
+// checkReserved returns an error for the first flag in fs, by name, which
+// maps to a reserved variable.
+func (o *options) checkReserved(fs *flag.FlagSet, prefix string) error {
+	if len(o.reserved) == 0 {
+		return nil
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, name := range o.varNames(fs, prefix, f) {
+			if o.reserved[strings.ToUpper(name)] {
+				err = fmt.Errorf("flag %v maps to %v: %w", f.Name, name, ErrReserved)
+				return
+			}
+		}
+	})
+	return err
+}
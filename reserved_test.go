@@ -0,0 +1,37 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestOverrideWithReserved(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	fs.String("region", "", "")
+	fs.Parse([]string{"-region", "ca-central-1"})
+
+	// The variable doesn't need to be set, and the flag can be set.
+	source := MapSource{"APP_PORT": "8080"}
+	err := Override(fs, "APP_", WithSource(source), WithReserved("app_region"))
+	if !errors.Is(err, ErrReserved) {
+		t.Errorf("Override returned %v, want an error wrapping ErrReserved.", err)
+	}
+	if *port != 80 {
+		t.Errorf("flag port was set to %v despite the error.", *port)
+	}
+
+	if err := Override(fs, "APP_", WithSource(source), WithReserved("AWS_REGION")); err != nil {
+		t.Errorf("Override returned an error: %v", err)
+	}
+	if *port != 8080 {
+		t.Errorf("flag port was %v, want 8080.", *port)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"time"
+)
+
+// Patterns matching the values each type of flag accepts.
+const (
+	boolPattern     = `^(1|0|t|f|T|F|true|false|TRUE|FALSE|True|False)$`
+	intPattern      = `^[+-]?(0[xX][0-9a-fA-F_]+|0[bB][01_]+|0[oO]?[0-7_]*|[1-9][0-9_]*)$`
+	uintPattern     = `^(0[xX][0-9a-fA-F_]+|0[bB][01_]+|0[oO]?[0-7_]*|[1-9][0-9_]*)$`
+	floatPattern    = `^[+-]?([0-9_]+\.?[0-9_]*|\.[0-9_]+)([eE][+-]?[0-9]+)?$|^[+-]?(Inf|inf|Infinity|NaN)$`
+	durationPattern = `^[+-]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+)$`
+)
+
+// schemaProperty describes one variable in a JSON Schema.
+type schemaProperty struct {
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default"`
+	Pattern     string `json:"pattern,omitempty"`
+	Flag        string `json:"x-flag"`
+	FlagType    string `json:"x-flag-type,omitempty"`
+}
+
+// WriteJSONSchema writes a JSON Schema to w describing the variables for
+// the flags in fs, so that external tools can check env files and
+// ConfigMaps against the program's actual configuration surface. The schema
+// is for an object whose properties are the variables Override tries first
+// for each flag, all strings, since that's what the environment holds.
+// Flags of the types in the flag package have patterns matching the values
+// they accept. Each property records its flag's usage message, default and
+// name, and the flags bound with Required are required. Other variables are
+// allowed. It accepts the same options as Override.
+func WriteJSONSchema(w io.Writer, fs *flag.FlagSet, prefix string, opts ...Option) error {
+
+	o := newOptions(opts)
+	properties := make(map[string]schemaProperty)
+	fs.VisitAll(func(f *flag.Flag) {
+		names := o.varNames(fs, prefix, f)
+		flagType, pattern := flagPattern(f)
+		properties[names[0]] = schemaProperty{
+			Type:        "string",
+			Description: f.Usage,
+			Default:     f.DefValue,
+			Pattern:     pattern,
+			Flag:        f.Name,
+			FlagType:    flagType,
+		}
+	})
+	required := []string{}
+	for _, f := range requiredFlags(fs) {
+		required = append(required, o.varNames(fs, prefix, f)[0])
+	}
+
+	schema := struct {
+		Schema     string                    `json:"$schema"`
+		Title      string                    `json:"title,omitempty"`
+		Type       string                    `json:"type"`
+		Properties map[string]schemaProperty `json:"properties"`
+		Required   []string                  `json:"required"`
+	}{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      fs.Name(),
+		Type:       "object",
+		Properties: properties,
+		Required:   required,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// flagPattern returns the name of f's type, along with a pattern matching
+// the values it accepts, if f is one of the flag package's types.
+func flagPattern(f *flag.Flag) (string, string) {
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return "", ""
+	}
+	switch g.Get().(type) {
+	case bool:
+		return "bool", boolPattern
+	case int, int64:
+		return "int", intPattern
+	case uint, uint64:
+		return "uint", uintPattern
+	case float64:
+		return "float", floatPattern
+	case time.Duration:
+		return "duration", durationPattern
+	case string:
+		return "string", ""
+	}
+	return "", ""
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestWriteJSONSchema(t *testing.T) {
+
+	fs := flag.NewFlagSet("scanner", flag.ContinueOnError)
+	fs.Int("port", 80, "the port")
+	fs.Duration("timeout", 0, "")
+	var token string
+	Bind(fs, &token, "token", "", "the token", BindOpts{Required: true})
+
+	var buf bytes.Buffer
+	if err := WriteJSONSchema(&buf, fs, "APP_"); err != nil {
+		t.Fatalf("WriteJSONSchema returned an error: %v", err)
+	}
+	var schema struct {
+		Title      string
+		Type       string
+		Properties map[string]map[string]string
+		Required   []string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("unable to read the schema: %v", err)
+	}
+	if schema.Title != "scanner" || schema.Type != "object" || !reflect.DeepEqual(schema.Required, []string{"APP_TOKEN"}) {
+		t.Errorf("schema had title %q, type %q and required %v.", schema.Title, schema.Type, schema.Required)
+	}
+	want := map[string]string{"type": "string", "description": "the port", "default": "80",
+		"pattern": intPattern, "x-flag": "port", "x-flag-type": "int"}
+	if !reflect.DeepEqual(schema.Properties["APP_PORT"], want) {
+		t.Errorf("schema for APP_PORT was %v, want %v.", schema.Properties["APP_PORT"], want)
+	}
+	if len(schema.Properties) != 3 {
+		t.Errorf("schema had %v properties, want 3.", len(schema.Properties))
+	}
+}
+
+func TestFlagPatterns(t *testing.T) {
+
+	// The patterns agree with the flags' Set methods.
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("bool", false, "")
+	fs.Int("int", 0, "")
+	fs.Uint("uint", 0, "")
+	fs.Float64("float", 0, "")
+	fs.Duration("duration", 0, "")
+	values := []string{"", "0", "1", "-1", "+7", "true", "F", "yes", "0x1F", "0b101", "017", "0o17",
+		"1_000", "1.5", ".5", "1e3", "-2.5E-3", "Inf", "NaN", "1h30m", "-1.5h", "300ms", "1d", "10", "abc"}
+	for _, name := range []string{"bool", "int", "uint", "float", "duration"} {
+		_, pattern := flagPattern(fs.Lookup(name))
+		re := regexp.MustCompile(pattern)
+		for _, value := range values {
+			accepted := fs.Set(name, value) == nil
+			if matched := re.MatchString(value); matched != accepted {
+				t.Errorf("the %v pattern matching %q is %v, but Set accepting it is %v.", name, value, matched, accepted)
+			}
+		}
+	}
+}
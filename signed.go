@@ -0,0 +1,62 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrBadSignature is wrapped by the error SignedSource returns when a
+// document's signature doesn't verify.
+var ErrBadSignature = errors.New("signature verification failed")
+
+// A SignedFetchFunc fetches a configuration document and its detached
+// signature from a remote service.
+type SignedFetchFunc func(ctx context.Context) (document, signature []byte, err error)
+
+// SignedSource returns a Source holding the values in a document fetched
+// with fetch, after checking its ed25519 signature against one of keys, so
+// that a compromised configuration endpoint can't change a program's
+// behaviour without also holding the signing key. Several keys can be
+// given so the signing key can be rotated. The signature is either the 64
+// raw bytes or their standard base64 encoding, with surrounding whitespace
+// ignored. The document is a JSON object keyed by variable name, whose
+// values are read like those given to WithJSONVar. Nothing in a document
+// is used unless its signature verifies.
+func SignedSource(ctx context.Context, fetch SignedFetchFunc, keys ...ed25519.PublicKey) (Source, error) {
+	return FetchSource(ctx, "signed", func(ctx context.Context) (map[string]string, error) {
+		document, signature, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !verifySignature(document, signature, keys) {
+			return nil, ErrBadSignature
+		}
+		return jsonValues(document)
+	})
+}
+
+// verifySignature reports whether signature is a signature of document
+// made with any of keys.
+func verifySignature(document, signature []byte, keys []ed25519.PublicKey) bool {
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+		if err != nil {
+			return false
+		}
+		signature = decoded
+	}
+	for _, key := range keys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, document, signature) {
+			return true
+		}
+	}
+	return false
+}
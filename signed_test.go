@@ -0,0 +1,59 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSignedSource(t *testing.T) {
+
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := []byte(`{"APP_PORT": 7777, "APP_HOST": "example.com"}`)
+	signature := ed25519.Sign(private, document)
+	fetch := func(document, signature []byte) SignedFetchFunc {
+		return func(context.Context) ([]byte, []byte, error) { return document, signature, nil }
+	}
+
+	encoded := []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+	for _, sig := range [][]byte{signature, encoded} {
+		src, err := SignedSource(context.Background(), fetch(document, sig), old, public)
+		if err != nil {
+			t.Fatalf("SignedSource returned an error: %v", err)
+		}
+		if v, ok := src.Lookup("APP_PORT"); !ok || v != "7777" {
+			t.Errorf("APP_PORT was %q, %v.", v, ok)
+		}
+	}
+
+	tampered := []byte(`{"APP_PORT": 1, "APP_HOST": "example.com"}`)
+	for name, f := range map[string]SignedFetchFunc{
+		"tampered":  fetch(tampered, signature),
+		"wrong key": fetch(document, ed25519.Sign(ed25519.NewKeyFromSeed(make([]byte, 32)), document)),
+		"garbage":   fetch(document, []byte("not a signature")),
+	} {
+		if _, err := SignedSource(context.Background(), f, public); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("SignedSource returned %v for a %v signature.", err, name)
+		}
+	}
+
+	down := errors.New("connection refused")
+	failing := func(context.Context) ([]byte, []byte, error) { return nil, nil, down }
+	if _, err := SignedSource(context.Background(), failing, public); !errors.Is(err, down) {
+		t.Errorf("SignedSource returned %v, want the fetch error.", err)
+	}
+}
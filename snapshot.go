@@ -0,0 +1,135 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// The origins of a flag's value in a Snapshot.
+const (
+	OriginDefault     = "default"      // The flag has its default value.
+	OriginCommandLine = "command line" // The flag was set while parsing arguments.
+	OriginOverride    = "override"     // The flag was set by Override.
+)
+
+// A Snapshot records the resolved configuration of a FlagSet, for example
+// at startup, so it can be attached to incident reports and replayed.
+type Snapshot struct {
+	Taken time.Time      `json:"taken"`
+	Flags []FlagSnapshot `json:"flags"`
+}
+
+// A FlagSnapshot records the value of one flag and where it came from.
+type FlagSnapshot struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`  // Redacted for secret flags.
+	Var    string `json:"var"`    // The variable Override uses for the flag.
+	Origin string `json:"origin"` // One of the Origin constants.
+
+	// Redacted is true if Value is the redacted placeholder rather than the
+	// flag's value.
+	Redacted bool `json:"redacted,omitempty"`
+
+	// Source and Set describe overridden flags, as in their Provenance.
+	Source string    `json:"source,omitempty"`
+	Set    time.Time `json:"set,omitempty"`
+}
+
+// TakeSnapshot records the current value of every flag in fs, except those
+// defined with flag.Func or flag.BoolFunc. The report from the Override call
+// which configured fs, which may be nil, supplies the provenance of the
+// overridden flags. It accepts the same options as Override; the values of
+// secret flags, and of flags the report shows were set to secrets, like
+// those decrypted by WithKMSDecrypt, are redacted unless WithSecretsIncluded
+// is given.
+func TakeSnapshot(fs *flag.FlagSet, prefix string, r *Report, opts ...Option) *Snapshot {
+
+	provenance := make(map[string]Provenance)
+	if r != nil {
+		for _, p := range r.Overridden {
+			provenance[p.Flag] = p
+		}
+	}
+	set := setFlags(fs)
+
+	o := newOptions(opts)
+	s := &Snapshot{Taken: time.Now(), Flags: []FlagSnapshot{}}
+	for _, v := range effectiveVars(fs, prefix, o) {
+		fsnap := FlagSnapshot{Name: v.flag.Name, Value: v.value, Var: v.name, Origin: OriginDefault, Redacted: v.redacted}
+		if p, ok := provenance[v.flag.Name]; ok {
+			fsnap.Origin, fsnap.Var, fsnap.Source, fsnap.Set = OriginOverride, p.Var, p.Source, p.Time
+			// Values which were secrets when they were set, because they
+			// were decrypted for example, are redacted too.
+			if p.Secret && !o.includeSecrets {
+				fsnap.Value, fsnap.Redacted = redacted, true
+			}
+		} else if set[v.flag.Name] {
+			fsnap.Origin = OriginCommandLine
+		}
+		s.Flags = append(s.Flags, fsnap)
+	}
+	return s
+}
+
+// Write writes s to w as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// WriteFile writes s to the file at path, replacing it if it exists.
+func (s *Snapshot) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadSnapshot reads a Snapshot written by Write from r.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+	return &s, nil
+}
+
+// ReadSnapshotFile reads a Snapshot from the file at path.
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
+
+// Source returns a Source holding the value of each flag in s under its
+// variable name, so a recorded configuration can be replayed with Override.
+// Flags with their default values are left out, and so are flags whose
+// values were redacted, which keep whatever value they are given otherwise;
+// take the snapshot with WithSecretsIncluded to replay them too.
+func (s *Snapshot) Source() Source {
+	values := make(MapSource)
+	for _, f := range s.Flags {
+		if f.Origin != OriginDefault && !f.Redacted {
+			values[f.Var] = f.Value
+		}
+	}
+	return namedSource{values, "snapshot:" + s.Taken.Format(time.RFC3339)}
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"encoding/base64"
+	"flag"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Int("port", 80, "")
+	fs.String("host", "localhost", "")
+	fs.String("name", "default", "")
+	var token string
+	Bind(fs, &token, "token", "", "", BindOpts{Secret: true})
+	fs.Parse([]string{"-host", "example.com"})
+
+	var r Report
+	source := MapSource{"APP_PORT": "8080", "APP_TOKEN": "hunter2"}
+	if err := Override(fs, "APP_", WithSource(source), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := TakeSnapshot(fs, "APP_", &r).WriteFile(path); err != nil {
+		t.Fatalf("WriteFile returned an error: %v", err)
+	}
+	s, err := ReadSnapshotFile(path)
+	if err != nil {
+		t.Fatalf("ReadSnapshotFile returned an error: %v", err)
+	}
+
+	want := map[string]FlagSnapshot{
+		"host":  {Name: "host", Value: "example.com", Var: "APP_HOST", Origin: OriginCommandLine},
+		"name":  {Name: "name", Value: "default", Var: "APP_NAME", Origin: OriginDefault},
+		"port":  {Name: "port", Value: "8080", Var: "APP_PORT", Origin: OriginOverride, Source: "overridefromenv.MapSource"},
+		"token": {Name: "token", Value: redacted, Var: "APP_TOKEN", Origin: OriginOverride, Source: "overridefromenv.MapSource", Redacted: true},
+	}
+	if len(s.Flags) != len(want) {
+		t.Fatalf("snapshot had %d flags, want %d.", len(s.Flags), len(want))
+	}
+	for _, f := range s.Flags {
+		if f.Origin == OriginOverride && f.Set.IsZero() {
+			t.Errorf("snapshot of %v had no time.", f.Name)
+		}
+		f.Set = want[f.Name].Set
+		if f != want[f.Name] {
+			t.Errorf("snapshot of %v was %+v, want %+v.", f.Name, f, want[f.Name])
+		}
+	}
+
+	// Replaying the snapshot reproduces the configuration.
+	replay := flag.NewFlagSet("test", flag.ExitOnError)
+	host := replay.String("host", "localhost", "")
+	port := replay.Int("port", 80, "")
+	var replayed string
+	Bind(replay, &replayed, "token", "fallback", "", BindOpts{Secret: true})
+	if err := Override(replay, "APP_", WithSource(s.Source())); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *host != "example.com" || *port != 8080 {
+		t.Errorf("replayed flags were %q and %v.", *host, *port)
+	}
+	// The redacted secret isn't replayed, and one recorded in full is.
+	if replayed != "fallback" {
+		t.Errorf("replayed secret was %q, want it left alone.", replayed)
+	}
+	s = TakeSnapshot(fs, "APP_", &r, WithSecretsIncluded())
+	if v, _ := s.Source().Lookup("APP_TOKEN"); v != "hunter2" {
+		t.Errorf("snapshot with secrets included replayed the token as %q.", v)
+	}
+}
+
+func TestSnapshotDecryptedSecret(t *testing.T) {
+
+	decrypt := func(ciphertext []byte) ([]byte, error) { return ciphertext, nil }
+	source := MapSource{
+		"APP_PASSWORD": kmsPrefix + base64.StdEncoding.EncodeToString([]byte("PLAINSECRET")),
+		"APP_NOTE":     redacted,
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("note", "", "")
+	fs.String("password", "", "")
+	var r Report
+	if err := Override(fs, "APP_", WithSource(source), WithKMSDecrypt(decrypt), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+
+	s := TakeSnapshot(fs, "APP_", &r)
+	if f := s.Flags[1]; f.Value != redacted || !f.Redacted {
+		t.Errorf("snapshot held %+v for a decrypted value, want it redacted.", f)
+	}
+	// A value which only looks like the placeholder isn't a secret.
+	if f := s.Flags[0]; f.Redacted {
+		t.Errorf("snapshot held %+v for a value which isn't a secret.", f)
+	}
+	if v, ok := s.Source().Lookup("APP_NOTE"); !ok || v != redacted {
+		t.Errorf("the snapshot's source held %q for the note.", v)
+	}
+	s = TakeSnapshot(fs, "APP_", &r, WithSecretsIncluded())
+	if s.Flags[1].Value != "PLAINSECRET" {
+		t.Errorf("snapshot held %q with secrets included.", s.Flags[1].Value)
+	}
+}
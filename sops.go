@@ -0,0 +1,55 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sopsCommand is the sops executable used to decrypt files.
+var sopsCommand = "sops"
+
+// SopsFile returns a Source holding the values in the SOPS encrypted file at
+// path, which may be a dotenv, JSON, or YAML file with a flat set of keys.
+// The file is decrypted by running sops, which must be installed, so age,
+// PGP, and cloud KMS keys are found the way sops usually finds them. The
+// decrypted values are redacted like those of flags bound with Secret.
+func SopsFile(path string) (Source, error) {
+	values, err := decryptSops(path)
+	if err != nil {
+		return nil, err
+	}
+	return secretSource{namedSource{values, "sops:" + path}}, nil
+}
+
+// decryptSops runs sops to decrypt the file at path into dotenv form.
+func decryptSops(path string) (MapSource, error) {
+	cmd := exec.Command(sopsCommand, "--decrypt", "--output-type", "dotenv", path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %v", err, msg)
+		}
+		return nil, fmt.Errorf("unable to decrypt %v with sops: %w", path, err)
+	}
+	values, err := parseDotenv(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read decrypted %v: %w", path, err)
+	}
+	return values, nil
+}
+
+// isSopsDotenv reports whether values were read from a dotenv file
+// encrypted by sops, which stores its metadata in keys like sops_mac.
+func isSopsDotenv(values MapSource) bool {
+	_, ok := values["sops_mac"]
+	return ok
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeSops replaces the sops executable with a script which prints output.
+func fakeSops(t *testing.T, output string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sops is a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "sops")
+	err := os.WriteFile(script, []byte("#!/bin/sh\nprintf '%s' '"+output+"'\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := sopsCommand
+	t.Cleanup(func() { sopsCommand = old })
+	sopsCommand = script
+}
+
+func TestDotenvFileSops(t *testing.T) {
+
+	fakeSops(t, "APP_PASSWORD=decrypted\n")
+
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "secrets.env")
+	os.WriteFile(encrypted, []byte("APP_PASSWORD=ENC[AES256_GCM,data:abc]\nsops_mac=ENC[AES256_GCM,data:def]\nsops_version=3.8.1\n"), 0600)
+	plain := filepath.Join(dir, "plain.env")
+	os.WriteFile(plain, []byte("APP_PASSWORD=plain\n"), 0600)
+
+	for path, want := range map[string]string{encrypted: "decrypted", plain: "plain"} {
+		s, err := DotenvFile(path)
+		if err != nil {
+			t.Fatalf("DotenvFile returned an error: %v", err)
+		}
+		if v, _ := s.Lookup("APP_PASSWORD"); v != want {
+			t.Errorf("DotenvFile(%v) held %q, want %q.", path, v, want)
+		}
+		if secret := keySecret(s, "APP_PASSWORD"); secret != (path == encrypted) {
+			t.Errorf("DotenvFile(%v) said the value being a secret was %v.", path, secret)
+		}
+	}
+
+	s, err := SopsFile(filepath.Join(dir, "secrets.yaml"))
+	if err != nil {
+		t.Fatalf("SopsFile returned an error: %v", err)
+	}
+	if v, _ := s.Lookup("APP_PASSWORD"); v != "decrypted" {
+		t.Errorf("SopsFile held %q, want \"decrypted\".", v)
+	}
+	if !keySecret(s, "APP_PASSWORD") {
+		t.Error("SopsFile didn't treat the decrypted value as a secret.")
+	}
+}
+
+func TestSopsFileError(t *testing.T) {
+
+	old := sopsCommand
+	defer func() { sopsCommand = old }()
+	sopsCommand = filepath.Join(t.TempDir(), "missing-sops")
+
+	if _, err := SopsFile("secrets.env"); err == nil {
+		t.Error("SopsFile didn't return an error when sops couldn't run.")
+	}
+}
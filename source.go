@@ -0,0 +1,181 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// A Source is somewhere Override can look up values.
+type Source interface {
+	// Lookup retrieves the value stored under key.
+	// The boolean is false if key is not present.
+	Lookup(key string) (string, bool)
+}
+
+// SourceFunc adapts an ordinary lookup function to the Source interface.
+type SourceFunc func(key string) (string, bool)
+
+// Lookup calls fn(key).
+func (fn SourceFunc) Lookup(key string) (string, bool) {
+	return fn(key)
+}
+
+// A Lister is a Source which can list the keys it holds.
+// Functions like Unused need a Lister to find variables which aren't used.
+type Lister interface {
+	Source
+	// Keys returns the keys held by the source.
+	Keys() []string
+}
+
+// Environment is the Source backed by the process environment.
+// It is the default used by Override, and is a Lister.
+var Environment Source = envSource{}
+
+// envSource is the Source backed by the process environment.
+type envSource struct{}
+
+// Lookup retrieves the value of the environment variable called key.
+func (envSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// Keys returns the names of the variables in the environment.
+func (envSource) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 {
+			keys = append(keys, kv[:i])
+		}
+	}
+	return keys
+}
+
+// A MapSource is a Source backed by a map of variable names to values, so
+// tests and programs which embed others can pass values to Override through
+// the same code path as the environment, without changing the process's
+// environment:
+//
+//	Override(fs, "APP_", WithSource(MapSource{"APP_PORT": "7777"}))
+//
+// An existing map can be converted with MapSource(m).
+type MapSource map[string]string
+
+// Lookup retrieves the value stored under key.
+func (m MapSource) Lookup(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// Keys returns the keys in the map, sorted.
+func (m MapSource) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// snapshotThreshold is the number of flags above which Override snapshots
+// the environment instead of looking each variable up separately.
+const snapshotThreshold = 256
+
+// WithEnvironSnapshot makes Override read the whole environment once, keeping
+// the variables which start with the prefix, instead of looking up each
+// variable separately. This is done automatically for FlagSets with more
+// than a few hundred flags. It has no effect if another Source is used.
+func WithEnvironSnapshot() Option {
+	return func(o *options) { o.snapshot = true }
+}
+
+// snapshotEnviron replaces the Environment source with a snapshot,
+// if asked to or if fs is large.
+func (o *options) snapshotEnviron(fs *flag.FlagSet, prefix string) {
+	if o.source != Environment {
+		return
+	}
+	if !o.snapshot {
+		n := 0
+		fs.VisitAll(func(*flag.Flag) { n++ })
+		if n <= snapshotThreshold {
+			return
+		}
+	}
+	o.source = newEnvironSnapshot(strings.ToUpper(prefix))
+}
+
+// environSnapshot holds the environment variables which start with a prefix.
+// Other variables, like the explicit names given to Bind, are looked up in
+// the environment as usual. On Windows, where variable names are compared
+// without regard to case, names are held in upper case.
+type environSnapshot struct {
+	prefix string
+	vars   map[string]string
+}
+
+// newEnvironSnapshot reads the variables starting with prefix from the environment.
+func newEnvironSnapshot(prefix string) environSnapshot {
+	s := environSnapshot{prefix: prefix, vars: make(map[string]string)}
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 && strings.HasPrefix(foldEnvName(kv[:i]), prefix) {
+			s.vars[foldEnvName(kv[:i])] = kv[i+1:]
+		}
+	}
+	return s
+}
+
+// foldEnvName returns name in the form used to compare environment
+// variable names on this platform.
+func foldEnvName(name string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// Lookup retrieves the value of the environment variable called key.
+func (s environSnapshot) Lookup(key string) (string, bool) {
+	if !strings.HasPrefix(foldEnvName(key), s.prefix) {
+		return os.LookupEnv(key)
+	}
+	v, ok := s.vars[foldEnvName(key)]
+	return v, ok
+}
+
+// Keys returns the names of the variables in the snapshot, sorted, so that
+// Unused and the warnings about unused variables work with a snapshot too.
+func (s environSnapshot) Keys() []string {
+	return MapSource(s.vars).Keys()
+}
+
+// namedSource is a MapSource with a description, used in provenance.
+type namedSource struct {
+	MapSource
+	name string
+}
+
+// String returns the description of the source.
+func (s namedSource) String() string {
+	return s.name
+}
+
+// secretSource is a namedSource whose variables all hold secrets.
+type secretSource struct {
+	namedSource
+}
+
+// SecretKey reports whether the source has the variable called key.
+func (s secretSource) SecretKey(key string) bool {
+	_, ok := s.Lookup(key)
+	return ok
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestOverrideWithEnvironSnapshot(t *testing.T) {
+
+	t.Setenv("SNAPSHOTTEST_NAME", "fromenv")
+	t.Setenv("SNAPSHOTTEST_OTHER_TOKEN", "bound")
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	name := fs.String("name", "default", "")
+	var token string
+	Bind(fs, &token, "token", "", "", BindOpts{Env: "SNAPSHOTTEST_OTHER_TOKEN"})
+
+	if err := Override(fs, "snapshottest_", WithEnvironSnapshot()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *name != "fromenv" || token != "bound" {
+		t.Errorf("flags were %q and %q, want \"fromenv\" and \"bound\".", *name, token)
+	}
+}
+
+func TestOverrideLargeFlagSetSnapshot(t *testing.T) {
+
+	t.Setenv("SNAPSHOTTEST_FLAG-300", "300")
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	for i := 0; i < snapshotThreshold+100; i++ {
+		fs.Int(fmt.Sprintf("flag-%d", i), 0, "")
+	}
+	if err := Override(fs, "SNAPSHOTTEST_"); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if v, _ := Get[int](fs, "flag-300"); v != 300 {
+		t.Errorf("flag was %v, want 300.", v)
+	}
+}
+
+func TestEnvironSnapshot(t *testing.T) {
+
+	t.Setenv("SNAPSHOTTEST_A", "a")
+	t.Setenv("UNRELATEDSNAPSHOTTEST_B", "b")
+
+	s := newEnvironSnapshot("SNAPSHOTTEST_")
+	if _, ok := s.vars["UNRELATEDSNAPSHOTTEST_B"]; ok {
+		t.Error("The snapshot held a variable without the prefix.")
+	}
+	if v, ok := s.Lookup("SNAPSHOTTEST_A"); !ok || v != "a" {
+		t.Errorf("Lookup returned %q, %v.", v, ok)
+	}
+	if v, ok := s.Lookup("UNRELATEDSNAPSHOTTEST_B"); !ok || v != "b" {
+		t.Errorf("Lookup of a variable without the prefix returned %q, %v.", v, ok)
+	}
+}
+
+func TestMapSource(t *testing.T) {
+
+	values := map[string]string{"APP_PORT": "7777", "APP_HOST": "example.com"}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 8080, "")
+	if err := Override(fs, "APP_", WithSource(MapSource(values))); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 7777 {
+		t.Errorf("port was %v, want 7777.", *port)
+	}
+	if got := MapSource(values).Keys(); !reflect.DeepEqual(got, []string{"APP_HOST", "APP_PORT"}) {
+		t.Errorf("Keys returned %q.", got)
+	}
+}
+
+func TestEnvironSnapshotWarnings(t *testing.T) {
+
+	t.Setenv("ZZAPP_NAME", "fromenv")
+	t.Setenv("ZZAPP_TYPO", "oops")
+	for _, opts := range [][]Option{nil, {WithEnvironSnapshot()}} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String("name", "", "")
+		var r Report
+		if err := Override(fs, "ZZAPP_", append(opts, WithReport(&r))...); err != nil {
+			t.Fatalf("Override returned an error: %v", err)
+		}
+		if len(r.Warnings) != 1 || r.Warnings[0].Var != "ZZAPP_TYPO" {
+			t.Errorf("warnings with %v options were %v, want one for ZZAPP_TYPO.", len(opts), r.Warnings)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SpringConfigOptions says which configuration SpringConfigSource reads
+// from a Spring Cloud Config server.
+type SpringConfigOptions struct {
+	URI         string // The URL of the server.
+	Application string // The name of the application.
+	Profile     string // The profile, or comma separated profiles. Defaults to "default".
+	Label       string // The label, often a git branch. Optional.
+
+	// Prefix is put before each property name, which is then upper cased,
+	// like the flag names in VarName, to give the variable names. With the
+	// prefix APP_, the property port is read for the flag port.
+	Prefix string
+
+	Username, Password string       // For HTTP basic authentication, if Username isn't empty.
+	Client             *http.Client // http.DefaultClient if nil.
+}
+
+// SpringConfigSource returns a Source holding the properties a Spring Cloud
+// Config server serves for an application and profile, so Go services can
+// share the centralized configuration of the Java services around them.
+// When several property sources have the same property, the first one the
+// server lists wins, as it does for Spring. Properties which are null are
+// left out.
+func SpringConfigSource(ctx context.Context, opts SpringConfigOptions) (Source, error) {
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = "default"
+	}
+	segments := []string{url.PathEscape(opts.Application), url.PathEscape(profile)}
+	if opts.Label != "" {
+		// Spring uses (_) for slashes in labels.
+		parts := strings.Split(opts.Label, "/")
+		for i := range parts {
+			parts[i] = url.PathEscape(parts[i])
+		}
+		segments = append(segments, strings.Join(parts, "(_)"))
+	}
+	u := strings.TrimSuffix(opts.URI, "/") + "/" + strings.Join(segments, "/")
+
+	return FetchSource(ctx, "spring:"+opts.Application+"/"+profile, func(ctx context.Context) (map[string]string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if opts.Username != "" {
+			req.SetBasicAuth(opts.Username, opts.Password)
+		}
+		var environment struct {
+			PropertySources []struct {
+				Source map[string]any `json:"source"`
+			} `json:"propertySources"`
+		}
+		if err := getJSON(opts.Client, req, &environment); err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]string)
+		for _, ps := range environment.PropertySources {
+			for property, value := range ps.Source {
+				name := VarName(opts.Prefix, property)
+				if _, ok := values[name]; !ok && value != nil {
+					values[name] = fmt.Sprint(value)
+				}
+			}
+		}
+		return values, nil
+	})
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSpringConfigSource(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "config" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.EscapedPath() != "/scanner/prod/release(_)2.0" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"name": "scanner", "profiles": ["prod"], "propertySources": [
+			{"name": "scanner-prod.yml", "source": {"server.port": 8443, "verbose": false, "name": null}},
+			{"name": "scanner.yml", "source": {"server.port": 8080, "name": "scanner", "ratio": 12345678901234567890}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	opts := SpringConfigOptions{URI: srv.URL + "/", Application: "scanner", Profile: "prod", Label: "release/2.0",
+		Prefix: "APP_", Username: "config", Password: "secret"}
+	src, err := SpringConfigSource(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("SpringConfigSource returned an error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("server.port", 80, "")
+	verbose := fs.Bool("verbose", true, "")
+	name := fs.String("name", "", "")
+	ratio := fs.String("ratio", "", "")
+	if err := Override(fs, "APP_", WithSource(src)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8443 || *verbose || *name != "scanner" || *ratio != "12345678901234567890" {
+		t.Errorf("flags were server.port=%v, verbose=%v, name=%q and ratio=%q.", *port, *verbose, *name, *ratio)
+	}
+
+	opts.Password = "wrong"
+	if _, err := SpringConfigSource(context.Background(), opts); err == nil {
+		t.Error("SpringConfigSource didn't return an error when it wasn't authorized.")
+	}
+}
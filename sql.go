@@ -0,0 +1,40 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SQLSource returns a Source holding the rows of a configuration table,
+// for institutions whose configuration of record lives in a database. The
+// query must return two columns, the variable name and its value:
+//
+//	SQLSource(ctx, db, "SELECT key, value FROM config WHERE app = ?", "scanner")
+//
+// Rows with a NULL value are left out.
+func SQLSource(ctx context.Context, db *sql.DB, query string, args ...any) (Source, error) {
+	return FetchSource(ctx, "sql", func(ctx context.Context) (map[string]string, error) {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		values := make(map[string]string)
+		for rows.Next() {
+			var key string
+			var value sql.NullString
+			if err := rows.Scan(&key, &value); err != nil {
+				return nil, err
+			}
+			if value.Valid {
+				values[key] = value.String
+			}
+		}
+		return values, rows.Err()
+	})
+}
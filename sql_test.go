@@ -0,0 +1,88 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+)
+
+// configDriver is a database/sql driver whose only table is configRows.
+// Every query returns all of it.
+type configDriver struct{}
+
+var configRows = [][]driver.Value{{"APP_PORT", "8080"}, {"APP_HOST", nil}}
+
+func (configDriver) Open(string) (driver.Conn, error) { return configConn{}, nil }
+
+type configConn struct{}
+
+func (configConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "" {
+		return nil, errors.New("empty query")
+	}
+	return configStmt{}, nil
+}
+func (configConn) Close() error              { return nil }
+func (configConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type configStmt struct{}
+
+func (configStmt) Close() error  { return nil }
+func (configStmt) NumInput() int { return -1 }
+func (configStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (configStmt) Query([]driver.Value) (driver.Rows, error) { return &configResult{}, nil }
+
+type configResult struct{ next int }
+
+func (r *configResult) Columns() []string { return []string{"key", "value"} }
+func (r *configResult) Close() error      { return nil }
+func (r *configResult) Next(dest []driver.Value) error {
+	if r.next == len(configRows) {
+		return io.EOF
+	}
+	copy(dest, configRows[r.next])
+	r.next++
+	return nil
+}
+
+func init() {
+	sql.Register("overridefromenvconfig", configDriver{})
+}
+
+func TestSQLSource(t *testing.T) {
+
+	db, err := sql.Open("overridefromenvconfig", "")
+	if err != nil {
+		t.Fatalf("unable to open the database: %v", err)
+	}
+	defer db.Close()
+
+	src, err := SQLSource(context.Background(), db, "SELECT key, value FROM config WHERE app = ?", "scanner")
+	if err != nil {
+		t.Fatalf("SQLSource returned an error: %v", err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	host := fs.String("host", "localhost", "")
+	if err := Override(fs, "APP_", WithSource(src)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || *host != "localhost" {
+		t.Errorf("flags were port=%v and host=%q.", *port, *host)
+	}
+
+	if _, err := SQLSource(context.Background(), db, ""); err == nil {
+		t.Error("SQLSource didn't return an error for a bad query.")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"path"
+)
+
+// A GetParametersByPathFunc returns one page of the parameters directly
+// under path in AWS Systems Manager Parameter Store, keyed by full
+// parameter name, along with the token for the next page, which is empty
+// after the last page. With the AWS SDK for Go v2, it looks like this:
+//
+//	func(ctx context.Context, path, next string) (map[string]string, string, error) {
+//		in := &ssm.GetParametersByPathInput{Path: &path, WithDecryption: aws.Bool(true)}
+//		if next != "" {
+//			in.NextToken = &next
+//		}
+//		out, err := client.GetParametersByPath(ctx, in)
+//		if err != nil {
+//			return nil, "", err
+//		}
+//		params := make(map[string]string, len(out.Parameters))
+//		for _, p := range out.Parameters {
+//			params[aws.ToString(p.Name)] = aws.ToString(p.Value)
+//		}
+//		return params, aws.ToString(out.NextToken), nil
+//	}
+type GetParametersByPathFunc func(ctx context.Context, path, next string) (params map[string]string, nextToken string, err error)
+
+// SSMSource returns a Source holding the parameters directly under the
+// path, like /app/prod, keyed by the last element of their names, so the
+// flag port is set from /app/prod/APP_PORT with the prefix APP_. All the
+// parameters are fetched a page at a time with GetParametersByPath when
+// SSMSource is called, so a program with many flags makes a handful of
+// calls at startup instead of one for each flag. SecureString parameters
+// arrive decrypted, and the page function doesn't say which parameters they
+// were, so every value is redacted like those of flags bound with Secret.
+func SSMSource(ctx context.Context, getParameters GetParametersByPathFunc, dir string) (Source, error) {
+	return fetchSecretSource(ctx, "ssm:"+dir, func(ctx context.Context) (map[string]string, error) {
+		values := make(map[string]string)
+		next := ""
+		for {
+			params, token, err := getParameters(ctx, dir, next)
+			if err != nil {
+				return nil, err
+			}
+			for name, value := range params {
+				values[path.Base(name)] = value
+			}
+			if token == "" {
+				return values, nil
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			next = token
+		}
+	})
+}
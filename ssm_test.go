@@ -0,0 +1,60 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSSMSource(t *testing.T) {
+
+	pages := map[string]map[string]string{
+		"":  {"/app/prod/APP_PORT": "7777", "/app/prod/APP_HOST": "example.com"},
+		"2": {"/app/prod/APP_TOKEN": "s3cret"},
+	}
+	next := map[string]string{"": "2", "2": ""}
+	var calls []string
+	get := func(ctx context.Context, path, token string) (map[string]string, string, error) {
+		if path != "/app/prod" {
+			t.Errorf("parameters were fetched from %v.", path)
+		}
+		calls = append(calls, token)
+		return pages[token], next[token], nil
+	}
+
+	src, err := SSMSource(context.Background(), get, "/app/prod")
+	if err != nil {
+		t.Fatalf("SSMSource returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"", "2"}) {
+		t.Errorf("pages were fetched with tokens %q.", calls)
+	}
+	want := []string{"APP_HOST", "APP_PORT", "APP_TOKEN"}
+	got := src.(Lister).Keys()
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys returned %q, want %q.", got, want)
+	}
+	if v, _ := src.Lookup("APP_TOKEN"); v != "s3cret" {
+		t.Errorf("APP_TOKEN was %q.", v)
+	}
+	if !keySecret(src, "APP_TOKEN") {
+		t.Error("the parameters weren't treated as secrets.")
+	}
+	if got := sourceName(src); got != "ssm:/app/prod" {
+		t.Errorf("source was called %q.", got)
+	}
+
+	denied := errors.New("access denied")
+	failing := func(context.Context, string, string) (map[string]string, string, error) { return nil, "", denied }
+	if _, err := SSMSource(context.Background(), failing, "/app/prod"); !errors.Is(err, denied) {
+		t.Errorf("SSMSource returned %v, want the client's error.", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+)
+
+// systemVars holds well known variables which belong to the system, the
+// shell or the platform rather than to any one program.
+var systemVars = map[string]bool{
+	"APPDATA": true, "COMSPEC": true, "DISPLAY": true, "EDITOR": true,
+	"HOME": true, "HOSTNAME": true, "LANG": true, "LC_ALL": true,
+	"LOGNAME": true, "OLDPWD": true, "PATH": true, "PATHEXT": true,
+	"PWD": true, "SHELL": true, "SHLVL": true, "SYSTEMROOT": true,
+	"TEMP": true, "TERM": true, "TMP": true, "TMPDIR": true, "TZ": true,
+	"USER": true, "USERNAME": true, "USERPROFILE": true,
+}
+
+// WithSystemVars lets Override set flags from well known system variables,
+// like PATH, HOME, LANG and TERM. By default, a flag whose variable name
+// would be one of them, which happens when the prefix is empty, is left
+// alone with a warning, so a -path flag isn't silently set from $PATH.
+// Names given explicitly, with BindOpts.Env or WithUsageAnnotations, are
+// always read.
+func WithSystemVars() Option {
+	return func(o *options) { o.systemVars = true }
+}
+
+// isSystemVar reports whether Override must not read the variable called
+// name for f, because it belongs to the system. Names given explicitly,
+// with BindOpts.Env or a usage annotation, are always allowed.
+func (o *options) isSystemVar(f *flag.Flag, name string) bool {
+	if o.systemVars || !systemVars[strings.ToUpper(name)] {
+		return false
+	}
+	if b, ok := binding(f); ok && b.Env != "" {
+		return false
+	}
+	_, annotated := annotatedName(f)
+	return !(annotated && o.annotations)
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOverrideSystemVars(t *testing.T) {
+
+	source := MapSource{"PATH": "/usr/bin", "HOME": "/home/x", "PORT": "8080", "TERM": "xterm"}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	path := fs.String("path", "data", "")
+	port := fs.Int("port", 80, "")
+	var home string
+	Bind(fs, &home, "home", "", "", BindOpts{Env: "HOME"})
+	term := fs.String("terminal", "", "the terminal [env: TERM]")
+
+	var r Report
+	if err := Override(fs, "", WithSource(source), WithReport(&r), WithUsageAnnotations()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *path != "data" || *port != 8080 || home != "/home/x" || *term != "xterm" {
+		t.Errorf("flags were path=%q, port=%v, home=%q and terminal=%q.", *path, *port, home, *term)
+	}
+	if len(r.Warnings) != 1 || r.Warnings[0].Var != "PATH" {
+		t.Errorf("Override reported warnings %v, want one about PATH.", r.Warnings)
+	}
+
+	if err := Override(fs, "", WithSource(source), WithSystemVars()); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *path != "/usr/bin" {
+		t.Errorf("flag path was %q with WithSystemVars, want /usr/bin.", *path)
+	}
+}
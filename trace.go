@@ -0,0 +1,99 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// A Trace records how Override dealt with one flag, for diagnosing why a
+// variable isn't taking effect. Values aren't recorded, so traces are safe
+// to log even for secret flags.
+type Trace struct {
+	Flag    string        // The name of the flag.
+	Lookups []TraceLookup // The variables tried, in order.
+	// Outcome says what happened in the end, like "skipped, already set",
+	// "not found" or "set from APP_PORT".
+	Outcome string
+}
+
+// A TraceLookup records one variable Override tried for a flag.
+type TraceLookup struct {
+	Var   string // The name of the variable.
+	Found bool   // Whether the variable was in the source.
+	Note  string // Anything else about the lookup, like why it was skipped.
+}
+
+// String describes t on a single line.
+func (t Trace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "flag %v: ", t.Flag)
+	for _, l := range t.Lookups {
+		fmt.Fprintf(&b, "%v ", l.Var)
+		switch {
+		case l.Note != "":
+			fmt.Fprintf(&b, "(%v), ", l.Note)
+		case l.Found:
+			b.WriteString("(found), ")
+		default:
+			b.WriteString("(not found), ")
+		}
+	}
+	b.WriteString(t.Outcome)
+	return b.String()
+}
+
+// WithTrace makes Override call handle with a Trace for every flag in the
+// FlagSet, once it has finished, recording the variables it tried for the
+// flag and what it did with it.
+func WithTrace(handle func(Trace)) Option {
+	return func(o *options) { o.trace = handle }
+}
+
+// startTrace starts a Trace for f, if tracing is on. It returns nil if not.
+func (o *options) startTrace(f *flag.Flag) *Trace {
+	if o.trace == nil {
+		return nil
+	}
+	t := &Trace{Flag: f.Name}
+	o.traces = append(o.traces, t)
+	return t
+}
+
+// traceFor returns the Trace for the flag called name, or nil if it hasn't
+// been traced.
+func (o *options) traceFor(name string) *Trace {
+	for _, t := range o.traces {
+		if t.Flag == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// flushTraces passes the traces to the handler.
+func (o *options) flushTraces() {
+	for _, t := range o.traces {
+		o.trace(*t)
+	}
+	o.traces = nil
+}
+
+// try records a lookup in t. It is safe to call on a nil Trace.
+func (t *Trace) try(name string, found bool, note string) {
+	if t != nil {
+		t.Lookups = append(t.Lookups, TraceLookup{Var: name, Found: found, Note: note})
+	}
+}
+
+// finish records the outcome for t. It is safe to call on a nil Trace.
+func (t *Trace) finish(outcome string) {
+	if t != nil {
+		t.Outcome = outcome
+	}
+}
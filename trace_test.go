@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestOverrideWithTrace(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("host", "", "")
+	fs.Int("port", 80, "")
+	fs.String("name", "", "")
+	fs.Parse([]string{"-host", "example.com"})
+
+	source := MapSource{"APP_PORT": "8080"}
+	var traces []string
+	err := Override(fs, "APP_", WithSource(source), WithScopes("SERVE_"),
+		WithTrace(func(t Trace) { traces = append(traces, t.String()) }))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+
+	want := []string{
+		"flag host: skipped, already set",
+		"flag name: APP_SERVE_NAME (not found), APP_NAME (not found), not found",
+		"flag port: APP_SERVE_PORT (not found), APP_PORT (found), set from APP_PORT",
+	}
+	if len(traces) != len(want) {
+		t.Fatalf("Override traced %q, want %q.", traces, want)
+	}
+	for i := range want {
+		if traces[i] != want[i] {
+			t.Errorf("Override traced %q, want %q.", traces[i], want[i])
+		}
+	}
+}
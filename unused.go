@@ -0,0 +1,59 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"sort"
+	"strings"
+)
+
+// Unused returns the sorted names of the variables in the environment which
+// start with the prefix, but which don't correspond to any flag in fs.
+// These are usually left behind when flags are removed or renamed, but the
+// variables of other FlagSets sharing the prefix are included too, as
+// WithWarnings explains. It accepts the same options as Override. If the
+// source isn't a Lister, Unused returns nil.
+func Unused(fs *flag.FlagSet, prefix string, opts ...Option) []string {
+
+	return newOptions(opts).unused(fs, prefix)
+}
+
+// unused implements Unused.
+func (o *options) unused(fs *flag.FlagSet, prefix string) []string {
+
+	lister, ok := o.source.(Lister)
+	if !ok {
+		return nil
+	}
+
+	// Every name which could be read for a flag, set or not, is used.
+	used := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		for _, name := range o.varNames(fs, prefix, f) {
+			if name, ok, _ := o.checkName(f.Name, name); ok {
+				used[name] = true
+			}
+		}
+	})
+
+	if o.profileSelector != "" {
+		used[o.profileVar(prefix)] = true
+	}
+	for _, c := range o.composites {
+		used[c.name(prefix)] = true
+	}
+
+	unused := []string{}
+	upperPrefix := strings.ToUpper(prefix)
+	for _, key := range lister.Keys() {
+		if strings.HasPrefix(key, upperPrefix) && !used[key] {
+			unused = append(unused, key)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
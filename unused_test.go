@@ -0,0 +1,54 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestUnused(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Int("powerlevel", 0, "")
+	fs.String("name", "", "")
+	fs.Parse([]string{"-name", "set"})
+
+	source := MapSource{
+		"APP_POWERLEVEL": "9000",
+		"APP_NAME":       "ignored",
+		"APP_OLDNAME":    "stale",
+		"APP_COLOUR":     "stale",
+		"OTHER_VALUE":    "unrelated",
+	}
+	got := Unused(fs, "app_", WithSource(source))
+	want := []string{"APP_COLOUR", "APP_OLDNAME"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unused returned %q, want %q.", got, want)
+	}
+
+	if got := Unused(fs, "APP_", WithSource(SourceFunc(os.LookupEnv))); got != nil {
+		t.Errorf("Unused returned %q for a source which isn't a Lister.", got)
+	}
+}
+
+func TestUnusedEnvironment(t *testing.T) {
+
+	target := "OVERRIDEFROMENVUNUSEDTEST_STALE"
+	old := os.Getenv(target)
+	defer os.Setenv(target, old)
+	os.Setenv(target, "stale")
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.String("test", "", "")
+
+	got := Unused(fs, "OVERRIDEFROMENVUNUSEDTEST_")
+	if !reflect.DeepEqual(got, []string{target}) {
+		t.Errorf("Unused returned %q, want [%q].", got, target)
+	}
+}
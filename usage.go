@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"fmt"
+)
+
+// PrintDefaults prints the default values of all the flags in fs, like
+// fs.PrintDefaults does, noting which of the flags are currently overridden
+// and by which environment variable. It accepts the same options as Override.
+func PrintDefaults(fs *flag.FlagSet, prefix string, opts ...Option) {
+
+	o := newOptions(opts)
+	overrides, _ := o.find(fs, prefix)
+
+	// Add a note to the usage message of each overridden flag,
+	// and put the original messages back once they're printed.
+	for _, p := range overrides {
+		defer func(f *flag.Flag, usage string) { f.Usage = usage }(p.flag, p.flag.Usage)
+		p.flag.Usage = fmt.Sprintf("%v (overridden by environment variable %v)", p.flag.Usage, p.name)
+	}
+	fs.PrintDefaults()
+}
+
+// Usage returns a function suitable for fs.Usage, which prints a usage
+// message like the flag package's default, using PrintDefaults to list the
+// flags.
+func Usage(fs *flag.FlagSet, prefix string, opts ...Option) func() {
+	return func() {
+		if fs.Name() == "" {
+			fmt.Fprintf(fs.Output(), "Usage:\n")
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+		}
+		PrintDefaults(fs, prefix, opts...)
+	}
+}
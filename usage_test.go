@@ -0,0 +1,42 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+
+	fs := flag.NewFlagSet("scanner", flag.ContinueOnError)
+	var b strings.Builder
+	fs.SetOutput(&b)
+	fs.Int("powerlevel", 0, "power level")
+	fs.String("name", "", "the `name` to use")
+	fs.Bool("verbose", false, "verbose output")
+	fs.Parse([]string{"-verbose"})
+
+	source := MapSource{"APP_POWERLEVEL": "9000", "APP_VERBOSE": "false"}
+	fs.Usage = Usage(fs, "APP_", WithSource(source))
+	fs.Usage()
+
+	want := `Usage of scanner:
+  -name name
+    	the name to use
+  -powerlevel int
+    	power level (overridden by environment variable APP_POWERLEVEL)
+  -verbose
+    	verbose output
+`
+	if b.String() != want {
+		t.Errorf("Usage printed:\n%v\nwant:\n%v", b.String(), want)
+	}
+	if fs.Lookup("powerlevel").Usage != "power level" {
+		t.Error("The flag's usage message wasn't restored.")
+	}
+}
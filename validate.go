@@ -0,0 +1,95 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithMaxLength makes values longer than limit bytes an error, rather than
+// passing them to the flag, so that a megabyte of output from a broken
+// template never reaches a program. WithMaxLengthFor gives particular flags
+// their own limits. A limit of zero means no limit.
+func WithMaxLength(limit int) Option {
+	return func(o *options) { o.maxLength = limit }
+}
+
+// WithMaxLengthFor gives the named flags their own limit, in place of the
+// one given with WithMaxLength, for flags which hold certificates or other
+// long values. A limit of zero means the flags have no limit.
+func WithMaxLengthFor(limit int, names ...string) Option {
+	return func(o *options) {
+		if o.maxLengths == nil {
+			o.maxLengths = make(map[string]int)
+		}
+		for _, name := range names {
+			o.maxLengths[name] = limit
+		}
+	}
+}
+
+// WithRejectControl makes values holding control characters an error,
+// rather than passing them to the flag, so a variable can't smuggle NULs,
+// ANSI escape sequences or extra lines into a program's logs or terminal.
+// Tabs are allowed. Flags which need other control characters, like
+// newlines in certificates, can be given with WithControlAllowed. Values
+// are escaped in the error, so printing it is safe.
+func WithRejectControl() Option {
+	return func(o *options) { o.rejectControl = true }
+}
+
+// WithControlAllowed exempts the named flags from WithRejectControl.
+func WithControlAllowed(names ...string) Option {
+	return func(o *options) {
+		if o.controlAllowed == nil {
+			o.controlAllowed = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.controlAllowed[name] = true
+		}
+	}
+}
+
+// validate checks p's value against the limits on values, and for control
+// characters.
+func (o *options) validate(p *pending) error {
+	limit, ok := o.maxLengths[p.flag.Name]
+	if !ok {
+		limit = o.maxLength
+	}
+	if limit > 0 && len(p.value) > limit {
+		n := len(p.value)
+		// The error quotes the value, so only the start of it is kept.
+		p.value = truncate(p.value, 32)
+		return fmt.Errorf("value is %v bytes long, longer than the limit of %v", n, limit)
+	}
+	if o.rejectControl && !o.controlAllowed[p.flag.Name] {
+		for i, r := range p.value {
+			if r != '\t' && unicode.IsControl(r) {
+				// The error quotes the value, so it's escaped first.
+				p.value = strings.Trim(strconv.QuoteToGraphic(p.value), `"`)
+				return fmt.Errorf("value holds the control character %U at byte %v", r, i)
+			}
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes, without splitting a character,
+// marking it with an ellipsis if it was shortened.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxLength(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	name := fs.String("name", "", "")
+	cert := fs.String("cert", "", "")
+	long := strings.Repeat("x", 100)
+
+	env := MapSource{"APP_NAME": "short", "APP_CERT": long}
+	err := Override(fs, "APP_", WithSource(env), WithMaxLength(10), WithMaxLengthFor(0, "cert"))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *name != "short" || *cert != long {
+		t.Errorf("flags were %q and %q.", *name, *cert)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	name = fs.String("name", "", "")
+	env = MapSource{"APP_NAME": strings.Repeat("é", 1000)}
+	err = Override(fs, "APP_", WithSource(env), WithMaxLength(1024))
+	if err == nil || !strings.Contains(err.Error(), "2000 bytes") || strings.Count(err.Error(), "é") != 16 {
+		t.Errorf("Override returned %v for a long value.", err)
+	}
+	if *name != "" {
+		t.Errorf("name was set to a long value.")
+	}
+}
+
+func TestWithRejectControl(t *testing.T) {
+
+	for _, value := range []string{"a\x00b", "\x1b[31mred", "line\ninjected", "del\x7f", "c1\u0085"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String("name", "", "")
+		err := Override(fs, "APP_", WithSource(MapSource{"APP_NAME": value}), WithRejectControl())
+		if err == nil {
+			t.Errorf("Override didn't return an error for %q.", value)
+			continue
+		}
+		if strings.IndexFunc(err.Error(), func(r rune) bool { return r < ' ' || r == 0x7f || r == 0x85 }) >= 0 {
+			t.Errorf("error for %q holds control characters: %q", value, err)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	name := fs.String("name", "", "")
+	cert := fs.String("cert", "", "")
+	env := MapSource{"APP_NAME": "tab\tseparated", "APP_CERT": "line one\nline two"}
+	err := Override(fs, "APP_", WithSource(env), WithRejectControl(), WithControlAllowed("cert"))
+	if err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *name != "tab\tseparated" || *cert != "line one\nline two" {
+		t.Errorf("flags were %q and %q.", *name, *cert)
+	}
+}
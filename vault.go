@@ -0,0 +1,317 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Vault is a HashiCorp Vault server, and the token VaultSource reads
+// secrets from it with. It logs in with Auth the first time it is used,
+// and keeps the token between calls to VaultSource, so a program which
+// reloads its configuration by creating its sources again doesn't log in
+// every time. Once half of the token's time to live has passed, the token
+// is renewed, or if it can't be, Vault logs in again, so long running
+// programs don't lose access to their configuration. A Vault must not be
+// copied after it is first used, and it is safe for concurrent use.
+type Vault struct {
+	URL       string       // The server's URL, from VAULT_ADDR by default.
+	Namespace string       // The Vault Enterprise namespace, if any.
+	Client    *http.Client // http.DefaultClient if nil.
+
+	// Auth says how to log in. By default, the token in VAULT_TOKEN is used.
+	Auth VaultAuth
+
+	mu     sync.Mutex
+	token  vaultLease
+	leases map[string]vaultSecret
+}
+
+// A VaultAuth is a way of logging in to Vault: VaultToken, VaultAppRole,
+// or VaultKubernetes.
+type VaultAuth interface {
+	// login returns a token for v, which it can use to make requests.
+	login(ctx context.Context, v *Vault) (vaultAuthResponse, error)
+}
+
+// VaultToken uses a token which was issued some other way, like a
+// periodic token given to the program by an operator. A token can't be
+// replaced when it expires, so it should be renewable.
+type VaultToken string
+
+func (t VaultToken) login(ctx context.Context, v *Vault) (vaultAuthResponse, error) {
+
+	// The token's time to live is looked up, so it can be renewed.
+	var lookup struct {
+		Data struct {
+			TTL       int  `json:"ttl"`
+			Renewable bool `json:"renewable"`
+		} `json:"data"`
+	}
+	if err := v.call(ctx, http.MethodGet, "auth/token/lookup-self", string(t), nil, &lookup); err != nil {
+		return vaultAuthResponse{}, err
+	}
+	var resp vaultAuthResponse
+	resp.Auth.ClientToken = string(t)
+	resp.Auth.LeaseDuration, resp.Auth.Renewable = lookup.Data.TTL, lookup.Data.Renewable
+	return resp, nil
+}
+
+// VaultAppRole logs in with an AppRole's role ID and secret ID.
+type VaultAppRole struct {
+	RoleID, SecretID string
+	Mount            string // Where the auth method is mounted, approle by default.
+}
+
+func (a VaultAppRole) login(ctx context.Context, v *Vault) (vaultAuthResponse, error) {
+	body := map[string]string{"role_id": a.RoleID, "secret_id": a.SecretID}
+	var resp vaultAuthResponse
+	err := v.call(ctx, http.MethodPost, "auth/"+mountOr(a.Mount, "approle")+"/login", "", body, &resp)
+	return resp, err
+}
+
+// VaultKubernetes logs in as a Vault role with the service account token
+// of the pod the program runs in.
+type VaultKubernetes struct {
+	Role  string
+	JWT   string // The service account token, the pod's by default.
+	Mount string // Where the auth method is mounted, kubernetes by default.
+}
+
+func (k VaultKubernetes) login(ctx context.Context, v *Vault) (vaultAuthResponse, error) {
+
+	// The pod's token is read each time, since the kubelet rotates it.
+	jwt := k.JWT
+	if jwt == "" {
+		token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+		if err != nil {
+			return vaultAuthResponse{}, fmt.Errorf("unable to read the service account token: %w", err)
+		}
+		jwt = strings.TrimSpace(string(token))
+	}
+	body := map[string]string{"role": k.Role, "jwt": jwt}
+	var resp vaultAuthResponse
+	err := v.call(ctx, http.MethodPost, "auth/"+mountOr(k.Mount, "kubernetes")+"/login", "", body, &resp)
+	return resp, err
+}
+
+// mountOr returns mount, or def if mount is empty.
+func mountOr(mount, def string) string {
+	if mount == "" {
+		return def
+	}
+	return strings.Trim(mount, "/")
+}
+
+// vaultAuthResponse is the response to a login, or to renewing a token.
+type vaultAuthResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// vaultLease is a token or a secret with a time to live, which is renewed
+// once half of it has passed. A zero expires never expires.
+type vaultLease struct {
+	id             string // The token, or the secret's lease ID.
+	renewable      bool
+	renew, expires time.Time
+}
+
+// newVaultLease returns a lease with the given time to live, in seconds,
+// starting now.
+func newVaultLease(id string, ttl int, renewable bool) vaultLease {
+	l := vaultLease{id: id, renewable: renewable}
+	if ttl > 0 {
+		start, d := now(), time.Duration(ttl)*time.Second
+		l.renew, l.expires = start.Add(d/2), start.Add(d)
+	}
+	return l
+}
+
+// due reports whether the lease should be renewed, or replaced if it
+// can't be.
+func (l vaultLease) due() bool {
+	return !l.expires.IsZero() && !now().Before(l.renew)
+}
+
+// vaultSecret is a secret with a lease, like a database password, which is
+// kept and renewed rather than read again, so that reloading doesn't issue
+// new credentials each time.
+type vaultSecret struct {
+	lease  vaultLease
+	values map[string]string
+}
+
+// VaultSource returns a Source holding the secret at path in Vault, with
+// each of its keys, put after prefix and upper cased like the flag names
+// in VarName, as a variable name. With the prefix APP_, the key port is
+// read for the flag port.
+//
+// The path is the one given to the HTTP API after /v1/. For version 2 of
+// the KV secrets engine, it includes data/, like secret/data/app/prod, and
+// the secret's metadata is left out. Secrets with leases, like the
+// credentials issued by the database secrets engine, are kept by v and
+// renewed, rather than read again, while their leases last, so the same
+// credentials are used from one reload to the next; once a lease can't be
+// renewed, the secret is read again.
+//
+// The values are redacted like those of flags bound with Secret.
+func VaultSource(ctx context.Context, v *Vault, path, prefix string) (Source, error) {
+	path = strings.Trim(path, "/")
+	return fetchSecretSource(ctx, "vault:"+path, func(ctx context.Context) (map[string]string, error) {
+		data, err := v.read(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]string, len(data))
+		for key, value := range data {
+			values[VarName(prefix, key)] = value
+		}
+		return values, nil
+	})
+}
+
+// read returns the data of the secret at path, renewing its lease, or
+// reading it again, as needed.
+func (v *Vault) read(ctx context.Context, path string) (map[string]string, error) {
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	token, err := v.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := v.leases[path]; ok && now().Before(s.lease.expires) {
+		if !s.lease.due() {
+			return s.values, nil
+		}
+		if s.lease.renewable {
+			var renewed struct {
+				LeaseDuration int  `json:"lease_duration"`
+				Renewable     bool `json:"renewable"`
+			}
+			body := map[string]string{"lease_id": s.lease.id}
+			if err := v.call(ctx, http.MethodPut, "sys/leases/renew", token, body, &renewed); err == nil && renewed.LeaseDuration > 0 {
+				s.lease = newVaultLease(s.lease.id, renewed.LeaseDuration, renewed.Renewable)
+				v.leases[path] = s
+				return s.values, nil
+			}
+		}
+	}
+	delete(v.leases, path)
+
+	var secret struct {
+		LeaseID       string          `json:"lease_id"`
+		LeaseDuration int             `json:"lease_duration"`
+		Renewable     bool            `json:"renewable"`
+		Data          json.RawMessage `json:"data"`
+	}
+	if err := v.call(ctx, http.MethodGet, path, token, nil, &secret); err != nil {
+		return nil, err
+	}
+	data := secret.Data
+	var kv struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if json.Unmarshal(data, &kv) == nil && kv.Data != nil && kv.Metadata != nil {
+		data = kv.Data
+	}
+	values, err := jsonValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the secret at %v: %w", path, err)
+	}
+	if secret.LeaseID != "" && secret.LeaseDuration > 0 {
+		if v.leases == nil {
+			v.leases = make(map[string]vaultSecret)
+		}
+		v.leases[path] = vaultSecret{newVaultLease(secret.LeaseID, secret.LeaseDuration, secret.Renewable), values}
+	}
+	return values, nil
+}
+
+// login returns v's token, logging in first if v has no token, or if the
+// token has expired or is due to be renewed and can't be. v.mu is held.
+func (v *Vault) login(ctx context.Context) (string, error) {
+
+	if v.token.id != "" && !v.token.due() {
+		return v.token.id, nil
+	}
+	if v.token.id != "" && v.token.renewable && now().Before(v.token.expires) {
+		var resp vaultAuthResponse
+		if err := v.call(ctx, http.MethodPost, "auth/token/renew-self", v.token.id, struct{}{}, &resp); err == nil {
+			v.token = newVaultLease(v.token.id, resp.Auth.LeaseDuration, resp.Auth.Renewable)
+			return v.token.id, nil
+		}
+	}
+
+	auth := v.Auth
+	if auth == nil {
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			return "", errors.New("no Vault auth method was given, and VAULT_TOKEN is empty")
+		}
+		auth = VaultToken(token)
+	}
+	resp, err := auth.login(ctx, v)
+	if err != nil {
+		return "", fmt.Errorf("unable to log in to Vault: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("unable to log in to Vault: no token was returned")
+	}
+	v.token = newVaultLease(resp.Auth.ClientToken, resp.Auth.LeaseDuration, resp.Auth.Renewable)
+	return v.token.id, nil
+}
+
+// call sends a request to the API at path with token, if it isn't empty,
+// and decodes the response into out. The body, if it isn't nil, is sent
+// as JSON.
+func (v *Vault) call(ctx context.Context, method, path, token string, body, out any) error {
+
+	base := v.URL
+	if base == "" {
+		base = os.Getenv("VAULT_ADDR")
+	}
+	if base == "" {
+		return errors.New("no Vault URL was given, and VAULT_ADDR is empty")
+	}
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+"/v1/"+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+	return getJSON(v.Client, req, out)
+}
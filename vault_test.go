@@ -0,0 +1,222 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVault is a Vault server with the auth methods, renewal endpoints,
+// and secrets VaultSource uses.
+type fakeVault struct {
+	mu       sync.Mutex
+	calls    []string
+	tokens   map[string]bool
+	issued   int
+	renew    bool // Whether tokens may be renewed.
+	reads    int  // The number of times credentials were issued.
+	released bool // Whether the credentials' lease has been revoked.
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+
+	login := func() {
+		f.issued++
+		token := fmt.Sprintf("s.%d", f.issued)
+		f.tokens[token] = true
+		fmt.Fprintf(w, `{"auth": {"client_token": %q, "lease_duration": 60, "renewable": true}}`, token)
+	}
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		if body["role_id"] != "scanner" || body["secret_id"] != "xyzzy" {
+			http.Error(w, `{"errors": ["invalid role or secret ID"]}`, http.StatusBadRequest)
+			return
+		}
+		login()
+		return
+	case "/v1/auth/k8s/login":
+		if body["role"] != "scanner" || body["jwt"] != "pod-token" {
+			http.Error(w, `{"errors": ["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		login()
+		return
+	}
+
+	token := r.Header.Get("X-Vault-Token")
+	if !f.tokens[token] {
+		http.Error(w, `{"errors": ["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/auth/token/lookup-self":
+		w.Write([]byte(`{"data": {"ttl": 60, "renewable": true}}`))
+	case "/v1/auth/token/renew-self":
+		if !f.renew {
+			http.Error(w, `{"errors": ["lease is not renewable"]}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"auth": {"client_token": %q, "lease_duration": 60, "renewable": true}}`, token)
+	case "/v1/secret/data/app/prod":
+		w.Write([]byte(`{"lease_duration": 0, "data": {"data": {"port": 8080, "token": "hunter2"}, "metadata": {"version": 3}}}`))
+	case "/v1/database/creds/scanner":
+		f.reads++
+		f.released = false
+		fmt.Fprintf(w, `{"lease_id": "database/creds/scanner/%d", "lease_duration": 60, "renewable": true, "data": {"username": "v-%d", "password": "p"}}`, f.reads, f.reads)
+	case "/v1/sys/leases/renew":
+		if f.released || body["lease_id"] != fmt.Sprintf("database/creds/scanner/%d", f.reads) {
+			http.Error(w, `{"errors": ["lease not found"]}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"lease_id": "database/creds/scanner", "lease_duration": 60, "renewable": true}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestVaultSource(t *testing.T) {
+
+	fake := &fakeVault{tokens: make(map[string]bool)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	v := &Vault{URL: srv.URL, Auth: VaultAppRole{RoleID: "scanner", SecretID: "xyzzy"}}
+	src, err := VaultSource(context.Background(), v, "secret/data/app/prod", "APP_")
+	if err != nil {
+		t.Fatalf("VaultSource returned an error: %v", err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	token := fs.String("token", "", "")
+	var r Report
+	if err := Override(fs, "APP_", WithSource(src), WithReport(&r)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || *token != "hunter2" {
+		t.Errorf("flags were port=%v and token=%q.", *port, *token)
+	}
+	for _, p := range r.Overridden {
+		if p.Value != redacted || p.Source != "vault:secret/data/app/prod" {
+			t.Errorf("flag %v was reported as %q from %v.", p.Flag, p.Value, p.Source)
+		}
+	}
+	if _, ok := src.Lookup("APP_METADATA"); ok {
+		t.Error("the secret's metadata was read as a value.")
+	}
+
+	bad := &Vault{URL: srv.URL, Auth: VaultAppRole{RoleID: "scanner", SecretID: "wrong"}}
+	if _, err := VaultSource(context.Background(), bad, "secret/data/app/prod", "APP_"); err == nil {
+		t.Error("VaultSource didn't return an error when it couldn't log in.")
+	}
+}
+
+func TestVaultAuth(t *testing.T) {
+
+	fake := &fakeVault{tokens: map[string]bool{"s.operator": true}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	defer func(dir string) { serviceAccountDir = dir }(serviceAccountDir)
+	serviceAccountDir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(serviceAccountDir, "token"), []byte("pod-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "s.operator")
+
+	for name, auth := range map[string]VaultAuth{
+		"token":      VaultToken("s.operator"),
+		"kubernetes": VaultKubernetes{Role: "scanner", Mount: "k8s"},
+		"default":    nil,
+	} {
+		v := &Vault{Auth: auth}
+		src, err := VaultSource(context.Background(), v, "/secret/data/app/prod/", "")
+		if err != nil {
+			t.Errorf("VaultSource with %v auth returned an error: %v", name, err)
+			continue
+		}
+		if value, _ := src.Lookup("TOKEN"); value != "hunter2" {
+			t.Errorf("VaultSource with %v auth read the token as %q.", name, value)
+		}
+	}
+
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := VaultSource(context.Background(), &Vault{}, "secret/data/app/prod", ""); err == nil {
+		t.Error("VaultSource didn't return an error without a way to log in.")
+	}
+}
+
+func TestVaultRenewal(t *testing.T) {
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	fake := &fakeVault{tokens: make(map[string]bool), renew: true}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	v := &Vault{URL: srv.URL, Auth: VaultAppRole{RoleID: "scanner", SecretID: "xyzzy"}}
+
+	// Each reload creates the source again.
+	reload := func() string {
+		t.Helper()
+		fake.calls = nil
+		src, err := VaultSource(context.Background(), v, "database/creds/scanner", "DB_")
+		if err != nil {
+			t.Fatalf("VaultSource returned an error: %v", err)
+		}
+		user, _ := src.Lookup("DB_USERNAME")
+		return user
+	}
+	check := func(step, user, wantUser string, want ...string) {
+		t.Helper()
+		if user != wantUser {
+			t.Errorf("%v: the username was %q, want %q.", step, user, wantUser)
+		}
+		if !reflect.DeepEqual(fake.calls, want) {
+			t.Errorf("%v: Vault was called with %q, want %q.", step, fake.calls, want)
+		}
+	}
+
+	check("first", reload(), "v-1", "POST /v1/auth/approle/login", "GET /v1/database/creds/scanner")
+
+	// Before half the leases have passed, the token and credentials are kept.
+	clock = clock.Add(20 * time.Second)
+	check("early", reload(), "v-1")
+
+	// After that, they are renewed rather than replaced.
+	clock = clock.Add(20 * time.Second)
+	check("renewed", reload(), "v-1", "POST /v1/auth/token/renew-self", "PUT /v1/sys/leases/renew")
+
+	// Once the credentials can't be renewed, they are read again, and once
+	// the token can't be, Vault is logged in to again.
+	fake.renew, fake.released = false, true
+	clock = clock.Add(40 * time.Second)
+	check("replaced", reload(), "v-2", "POST /v1/auth/token/renew-self", "POST /v1/auth/approle/login",
+		"PUT /v1/sys/leases/renew", "GET /v1/database/creds/scanner")
+
+	// Expired leases aren't renewed at all.
+	clock = clock.Add(2 * time.Minute)
+	check("expired", reload(), "v-3", "POST /v1/auth/approle/login", "GET /v1/database/creds/scanner")
+}
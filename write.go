@@ -0,0 +1,100 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WithSecretsIncluded makes functions which write out configuration, like
+// WriteDotenv, include the values of secret flags instead of redacting them.
+func WithSecretsIncluded() Option {
+	return func(o *options) { o.includeSecrets = true }
+}
+
+// WriteDotenv writes the current value of every flag in fs to w, in the
+// dotenv format read by DotenvSource, using the variable names Override
+// would look for, in order of flag name. The result can be loaded back
+// with DotenvFile and WithSource. It accepts the same options as Override.
+// Secret flags are written as comments naming their variables, without
+// their values, unless WithSecretsIncluded is given, so loading the file
+// back leaves them alone rather than setting them to a placeholder. Flags
+// defined with flag.Func or flag.BoolFunc are left out, since they have no
+// value to write.
+func WriteDotenv(w io.Writer, fs *flag.FlagSet, prefix string, opts ...Option) error {
+	bw := bufio.NewWriter(w)
+	for _, v := range effectiveVars(fs, prefix, newOptions(opts)) {
+		if v.redacted {
+			bw.WriteString("# " + v.name + " holds a secret, which was left out\n")
+			continue
+		}
+		bw.WriteString(v.name + "=" + quoteDotenv(v.value) + "\n")
+	}
+	return bw.Flush()
+}
+
+// Environ returns the current value of every flag in fs as a list of
+// variables, in the form "key=value", using the names Override would look
+// for, in order of flag name. The result can be assigned to exec.Cmd.Env,
+// usually along with os.Environ, so a child process using the same prefix
+// receives the parent's configuration. It accepts the same options as
+// Override. Secret flags are left out unless WithSecretsIncluded is given,
+// since a redacted value would be mistaken for the real one, and flags
+// defined with flag.Func or flag.BoolFunc are left out, since they have no
+// value to pass on.
+func Environ(fs *flag.FlagSet, prefix string, opts ...Option) []string {
+	return newOptions(opts).environ(fs, prefix, func(*flag.Flag) bool { return true })
+}
+
+// environ implements Environ for the flags for which keep returns true.
+func (o *options) environ(fs *flag.FlagSet, prefix string, keep func(*flag.Flag) bool) []string {
+	env := []string{}
+	for _, v := range effectiveVars(fs, prefix, o) {
+		if !keep(v.flag) || v.redacted {
+			continue
+		}
+		env = append(env, v.name+"="+v.value)
+	}
+	return env
+}
+
+// effectiveVar is a variable describing a flag's current value.
+type effectiveVar struct {
+	flag     *flag.Flag
+	name     string
+	value    string
+	redacted bool // Whether value is the redacted placeholder.
+}
+
+// effectiveVars returns a variable for each flag in fs, sorted by flag name,
+// holding the flag's current value, redacted if need be. Function flags are
+// left out. The name used is the one Override tries first for the flag.
+func effectiveVars(fs *flag.FlagSet, prefix string, o *options) []effectiveVar {
+	var vars []effectiveVar
+	fs.VisitAll(func(f *flag.Flag) {
+		if isFuncFlag(f) {
+			return
+		}
+		v := effectiveVar{flag: f, name: o.varNames(fs, prefix, f)[0], value: f.Value.String()}
+		if isSecret(f) && !o.includeSecrets {
+			v.value, v.redacted = redacted, true
+		}
+		vars = append(vars, v)
+	})
+	return vars
+}
+
+// quoteDotenv quotes value if it can't be written in a dotenv file as it is.
+func quoteDotenv(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"'#\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteDotenv(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	fs.Int("port", 80, "")
+	fs.String("greeting", "hello, world", "")
+	fs.String("empty", "", "")
+	fs.Duration("timeout", time.Minute, "")
+	fs.Func("callback", "", func(string) error { return nil })
+	var password string
+	Bind(fs, &password, "password", "hunter2", "", BindOpts{Secret: true, Env: "DB_PASSWORD"})
+	fs.Parse([]string{"-port", "8080"})
+
+	var b strings.Builder
+	if err := WriteDotenv(&b, fs, "APP_"); err != nil {
+		t.Fatalf("WriteDotenv returned an error: %v", err)
+	}
+	want := `APP_EMPTY=""
+APP_GREETING="hello, world"
+# DB_PASSWORD holds a secret, which was left out
+APP_PORT=8080
+APP_TIMEOUT=1m0s
+`
+	if b.String() != want {
+		t.Errorf("WriteDotenv wrote:\n%v\nwant:\n%v", b.String(), want)
+	}
+
+	b.Reset()
+	WriteDotenv(&b, fs, "APP_", WithSecretsIncluded())
+	if !strings.Contains(b.String(), "DB_PASSWORD=hunter2\n") {
+		t.Errorf("WriteDotenv didn't include the secret:\n%v", b.String())
+	}
+
+	// What was written can be read back.
+	s, err := DotenvSource(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("DotenvSource returned an error: %v", err)
+	}
+	loaded := flag.NewFlagSet("copy", flag.ExitOnError)
+	greeting := loaded.String("greeting", "", "")
+	port := loaded.Int("port", 0, "")
+	if err := Override(loaded, "APP_", WithSource(s)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *greeting != "hello, world" || *port != 8080 {
+		t.Errorf("flags read back were %q and %v.", *greeting, *port)
+	}
+}
+
+func TestEnviron(t *testing.T) {
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("port", 80, "")
+	fs.String("greeting", "hello, world", "")
+	fs.Func("callback", "", func(string) error { return nil })
+	var token string
+	Bind(fs, &token, "token", "s3cret", "", BindOpts{Secret: true})
+
+	got := strings.Join(Environ(fs, "app_"), "\n")
+	want := "APP_GREETING=hello, world\nAPP_PORT=80"
+	if got != want {
+		t.Errorf("Environ returned %q, want %q.", got, want)
+	}
+
+	got = strings.Join(Environ(fs, "APP_", WithSecretsIncluded()), "\n")
+	want = "APP_GREETING=hello, world\nAPP_PORT=80\nAPP_TOKEN=s3cret"
+	if got != want {
+		t.Errorf("Environ returned %q with secrets included, want %q.", got, want)
+	}
+
+	// The result round trips through Override.
+	child := flag.NewFlagSet("child", flag.ContinueOnError)
+	port := child.Int("port", 0, "")
+	greeting := child.String("greeting", "", "")
+	env := MapSource{}
+	for _, kv := range Environ(fs, "APP_") {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	if err := Override(child, "APP_", WithSource(env)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 80 || *greeting != "hello, world" {
+		t.Errorf("child flags were %v and %q.", *port, *greeting)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ConfigPaths returns the paths where the XDG Base Directory Specification
+// puts the configuration file called name for the program called app, most
+// important first: the user's directory, then the system's directories.
+// The user's directory is $XDG_CONFIG_HOME, or where os.UserConfigDir
+// says configuration belongs on the platform: ~/.config on Linux,
+// ~/Library/Application Support on macOS, and %AppData% on Windows. The
+// system's directories are those in $XDG_CONFIG_DIRS, or /etc/xdg outside
+// Windows. Relative directories are ignored, as the specification says. The
+// files needn't exist.
+func ConfigPaths(app, name string) []string {
+	var dirs []string
+	if home, ok := userConfigDir(); ok {
+		dirs = append(dirs, home)
+	}
+	system := os.Getenv("XDG_CONFIG_DIRS")
+	if system == "" && runtime.GOOS != "windows" {
+		system = "/etc/xdg"
+	}
+	for _, dir := range strings.Split(system, string(os.PathListSeparator)) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	paths := make([]string, len(dirs))
+	for i, dir := range dirs {
+		paths[i] = filepath.Join(dir, app, name)
+	}
+	return paths
+}
+
+// userConfigDir returns the user's configuration directory, as described
+// by ConfigPaths, and whether there is one.
+func userConfigDir() (string, bool) {
+	if home := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(home) {
+		return home, true
+	}
+	home, err := os.UserConfigDir()
+	return home, err == nil
+}
+
+// FindConfig returns the first of the ConfigPaths for app and name which is
+// a regular file, and whether there is one.
+func FindConfig(app, name string) (string, bool) {
+	for _, path := range ConfigPaths(app, name) {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// XDGConfigFile returns a layer holding the values in the configuration
+// file found by FindConfig, read as ConfigFile reads it, so a command line
+// tool gets the conventional configuration locations:
+//
+//	Load(fs, Layers{Defaults, XDGConfigFile("scanner", "config"), Env(prefix), Args(os.Args[1:])})
+//
+// Unlike ConfigFile, it isn't an error for there to be no file.
+func XDGConfigFile(app, name string) Layer {
+	return xdgLayer{app, name}
+}
+
+// xdgLayer finds its config file when it is applied.
+type xdgLayer struct {
+	app, name string
+}
+
+func (l xdgLayer) apply(fs *flag.FlagSet, done map[string]bool) error {
+	path, found := FindConfig(l.app, l.name)
+	if !found {
+		return nil
+	}
+	return fileLayer(path).apply(fs, done)
+}
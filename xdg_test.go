@@ -0,0 +1,77 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeConfig writes data to the file called name in dir/app, creating the
+// directories.
+func writeConfig(t *testing.T, dir, app, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, app, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigPaths(t *testing.T) {
+
+	home, system1, system2 := t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", system1+string(os.PathListSeparator)+"relative"+string(os.PathListSeparator)+system2)
+
+	want := []string{
+		filepath.Join(home, "scanner", "config"),
+		filepath.Join(system1, "scanner", "config"),
+		filepath.Join(system2, "scanner", "config"),
+	}
+	if got := ConfigPaths("scanner", "config"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigPaths returned %q, want %q.", got, want)
+	}
+
+	if _, found := FindConfig("scanner", "config"); found {
+		t.Error("FindConfig found a file which doesn't exist.")
+	}
+	path := writeConfig(t, system2, "scanner", "config", "powerlevel=10\n")
+	if got, found := FindConfig("scanner", "config"); !found || got != path {
+		t.Errorf("FindConfig returned %q, %v, want %q.", got, found, path)
+	}
+	path = writeConfig(t, home, "scanner", "config", "powerlevel=9000\n")
+	if got, found := FindConfig("scanner", "config"); !found || got != path {
+		t.Errorf("FindConfig returned %q, %v, want the user's file %q.", got, found, path)
+	}
+}
+
+func TestXDGConfigFile(t *testing.T) {
+
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", t.TempDir())
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	level := fs.Int("powerlevel", 0, "")
+	if err := Load(fs, Layers{Defaults, XDGConfigFile("scanner", "config"), Args(nil)}); err != nil {
+		t.Fatalf("Load returned an error without a config file: %v", err)
+	}
+
+	writeConfig(t, home, "scanner", "config", "powerlevel=9000\n")
+	if err := Load(fs, Layers{Defaults, XDGConfigFile("scanner", "config"), Args(nil)}); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if *level != 9000 {
+		t.Errorf("powerlevel was %v, want 9000.", *level)
+	}
+}
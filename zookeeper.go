@@ -0,0 +1,48 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"fmt"
+	"path"
+)
+
+// A ZooKeeperClient reads znodes. An implementation on top of a ZooKeeper
+// connection drops the znode stats it returns.
+type ZooKeeperClient interface {
+	// Children returns the names of the children of the znode at path.
+	Children(path string) ([]string, error)
+	// Get returns the data of the znode at path.
+	Get(path string) ([]byte, error)
+}
+
+// ZooKeeperSource returns a Source holding the data of each child of the
+// znode at the chroot path, keyed by the child's name, for infrastructure
+// where ZooKeeper is still the configuration system of record. With the
+// chroot /config/app and the prefix APP_, the flag port is set from the
+// znode /config/app/APP_PORT. The client doesn't take a context, so ctx is
+// checked between reads.
+func ZooKeeperSource(ctx context.Context, client ZooKeeperClient, chroot string) (Source, error) {
+	return FetchSource(ctx, "zookeeper:"+chroot, func(ctx context.Context) (map[string]string, error) {
+		children, err := client.Children(chroot)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]string, len(children))
+		for _, child := range children {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			data, err := client.Get(path.Join(chroot, child))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read znode %v: %w", child, err)
+			}
+			values[child] = string(data)
+		}
+		return values, nil
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Carleton University Library
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package overridefromenv
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeZooKeeper is a tree of znodes, keyed by path.
+type fakeZooKeeper map[string]string
+
+func (z fakeZooKeeper) Children(parent string) ([]string, error) {
+	var children []string
+	for p := range z {
+		if path.Dir(p) == parent {
+			children = append(children, path.Base(p))
+		}
+	}
+	if children == nil {
+		return nil, errors.New("no node")
+	}
+	sort.Strings(children)
+	return children, nil
+}
+
+func (z fakeZooKeeper) Get(p string) ([]byte, error) {
+	if data, ok := z[p]; ok {
+		return []byte(data), nil
+	}
+	return nil, errors.New("no node")
+}
+
+func TestZooKeeperSource(t *testing.T) {
+
+	zk := fakeZooKeeper{"/config/app/APP_PORT": "8080", "/config/app/APP_HOST": "zk.example.com", "/config/other/APP_NAME": "x"}
+	src, err := ZooKeeperSource(context.Background(), zk, "/config/app")
+	if err != nil {
+		t.Fatalf("ZooKeeperSource returned an error: %v", err)
+	}
+	keys := src.(Lister).Keys()
+	sort.Strings(keys)
+	if strings.Join(keys, " ") != "APP_HOST APP_PORT" {
+		t.Errorf("the source had keys %v.", keys)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	port := fs.Int("port", 80, "")
+	host := fs.String("host", "", "")
+	if err := Override(fs, "APP_", WithSource(src)); err != nil {
+		t.Fatalf("Override returned an error: %v", err)
+	}
+	if *port != 8080 || *host != "zk.example.com" {
+		t.Errorf("flags were port=%v and host=%q.", *port, *host)
+	}
+
+	if _, err := ZooKeeperSource(context.Background(), zk, "/missing"); err == nil {
+		t.Error("ZooKeeperSource didn't return an error for a missing chroot.")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ZooKeeperSource(ctx, zk, "/config/app"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ZooKeeperSource returned %v with a cancelled context.", err)
+	}
+}